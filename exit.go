@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/windows-fping/goping/ping"
+)
+
+// Exit codes, the same as fping's apart from exitAssertion
+const (
+	// exitAlive means every target answered, or enough of them with -reachable
+	exitAlive = 0
+	// exitUnreachable means some targets did not answer or raised a warning
+	exitUnreachable = 1
+	// exitUnresolved means some hostnames could not be resolved
+	exitUnresolved = 2
+	// exitUsage means the arguments were invalid
+	exitUsage = 3
+	// exitSystem means a system call failed, e.g. reading a file or opening a socket
+	exitSystem = 4
+	// exitAssertion means a target broke one of the -max-* assertions
+	exitAssertion = 5
+)
+
+// exitCode returns the exit code for the totals of a run. A failed assertion
+// always fails the run. With a reachable threshold the run succeeds as soon
+// as enough targets answered, otherwise every target has to.
+func exitCode(totals ping.Totals, reachable int, reachablePct float64) int {
+	if totals.Failed > 0 {
+		return exitAssertion
+	}
+	if reachable > 0 {
+		if totals.Alive >= reachable {
+			fmt.Printf("Enough hosts reachable (required: %d, reachable: %d)\n", reachable, totals.Alive)
+			return exitAlive
+		}
+		fmt.Printf("Not enough hosts reachable (required: %d, reachable: %d)\n", reachable, totals.Alive)
+		return exitUnreachable
+	}
+	if reachablePct > 0 {
+		pct := 0.0
+		if totals.Targets > 0 {
+			pct = float64(totals.Alive) / float64(totals.Targets) * 100
+		}
+		if pct >= reachablePct {
+			fmt.Printf("Enough hosts reachable (required: %g%%, reachable: %0.1f%%)\n", reachablePct, pct)
+			return exitAlive
+		}
+		fmt.Printf("Not enough hosts reachable (required: %g%%, reachable: %0.1f%%)\n", reachablePct, pct)
+		return exitUnreachable
+	}
+
+	switch {
+	case totals.Unresolved > 0:
+		return exitUnresolved
+	case totals.Alive < totals.Targets || totals.Warnings > 0:
+		// Warnings such as expiring certificates fail the run so scripts notice them
+		return exitUnreachable
+	}
+	return exitAlive
+}
+
+// readErrorCode returns the exit code for an error reading an input file,
+// which is a system error if the file could not be opened and invalid
+// arguments if its contents could not be parsed
+func readErrorCode(err error) int {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return exitSystem
+	}
+	return exitUsage
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/windows-fping/goping/ping"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name         string
+		totals       ping.Totals
+		reachable    int
+		reachablePct float64
+		expected     int
+	}{
+		{name: "All alive", totals: ping.Totals{Targets: 3, Alive: 3}, expected: exitAlive},
+		{name: "No targets", expected: exitAlive},
+		{name: "Some unreachable", totals: ping.Totals{Targets: 3, Alive: 2}, expected: exitUnreachable},
+		{name: "Warning", totals: ping.Totals{Targets: 1, Alive: 1, Warnings: 1}, expected: exitUnreachable},
+		{name: "Unresolved", totals: ping.Totals{Targets: 3, Alive: 2, Unresolved: 1}, expected: exitUnresolved},
+		{name: "Assertion failed", totals: ping.Totals{Targets: 3, Alive: 2, Unresolved: 1, Failed: 1}, expected: exitAssertion},
+		{name: "Enough reachable", totals: ping.Totals{Targets: 3, Alive: 2, Unresolved: 1}, reachable: 2, expected: exitAlive},
+		{name: "Not enough reachable", totals: ping.Totals{Targets: 3, Alive: 1}, reachable: 2, expected: exitUnreachable},
+		{name: "Enough reachable percentage", totals: ping.Totals{Targets: 4, Alive: 3}, reachablePct: 75, expected: exitAlive},
+		{name: "Not enough reachable percentage", totals: ping.Totals{Targets: 4, Alive: 3}, reachablePct: 80, expected: exitUnreachable},
+		{name: "Percentage without targets", reachablePct: 50, expected: exitUnreachable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := exitCode(test.totals, test.reachable, test.reachablePct); got != test.expected {
+				t.Errorf("exitCode() = %d, want %d", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestReadErrorCode(t *testing.T) {
+	_, openErr := os.Open("does-not-exist.txt")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "Missing file", err: openErr, expected: exitSystem},
+		{name: "Wrapped missing file", err: fmt.Errorf("reading targets: %w", openErr), expected: exitSystem},
+		{name: "Invalid contents", err: errors.New("invalid IP range"), expected: exitUsage},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := readErrorCode(test.err); got != test.expected {
+				t.Errorf("readErrorCode() = %d, want %d", got, test.expected)
+			}
+		})
+	}
+}
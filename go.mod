@@ -6,4 +6,4 @@ toolchain go1.24.1
 
 require golang.org/x/net v0.46.0
 
-require golang.org/x/sys v0.37.0 // indirect
+require golang.org/x/sys v0.37.0
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
+	"math/rand/v2"
 	"os"
 	"runtime"
 	"strings"
@@ -15,18 +19,14 @@ import (
 func main() {
 	if runtime.GOOS != "windows" {
 		fmt.Println("GoPing is designed specifically for Windows systems")
-		os.Exit(1)
-	}
-
-	// Check for administrator privileges
-	if !ping.IsAdmin() {
-		fmt.Println("GoPing requires administrator privileges to send ICMP packets")
-		fmt.Println("Please run this program as an administrator")
-		os.Exit(1)
+		os.Exit(exitSystem)
 	}
 
 	// Define flags/options
 	count := flag.Int("c", 1, "Number of pings to send to each target")
+	countReport := flag.Int("C", 0, "Like -c, but report every ping like fping -C and list every target's RTTs on stderr at the end")
+	elapsed := flag.Bool("e", false, "Report each target once as alive with its RTT or as unreachable, like fping -e")
+	timestamps := flag.Bool("D", false, "Show a Unix timestamp in front of every ping, like fping -D")
 	timeout := flag.Int("t", 500, "Timeout in milliseconds")
 	interval := flag.Int("i", 1000, "Interval in milliseconds between pings to the same target")
 	period := flag.Int("p", 25, "Period in milliseconds between pings to consecutive targets")
@@ -34,60 +34,146 @@ func main() {
 	unreachableOnly := flag.Bool("u", false, "Show only unreachable hosts")
 	quiet := flag.Bool("q", false, "Quiet mode - only show summary")
 	showStats := flag.Bool("s", false, "Show summary statistics")
+	showNames := flag.Bool("n", false, "Show targets by the name from their PTR record instead of their address")
+	showAddresses := flag.Bool("A", false, "Show targets by address instead of name, with -n show both")
+	allAddresses := flag.Bool("m", false, "Ping every IPv4 address a hostname resolves to and group them under the name")
 	inputFile := flag.String("f", "", "Read targets from a file")
-	cidrOrRange := flag.String("g", "", "Generate targets from IP range (start-end) or CIDR notation (x.x.x.x/y)")
+	var generate stringList
+	flag.Var(&generate, "g", "Generate targets from IP range (start-end), CIDR notation (x.x.x.x/y) or nmap-style octets (10.0-3.1-254.1), can be repeated")
+	var excludes stringList
+	flag.Var(&excludes, "exclude", "Never probe this IP, range or CIDR, can be repeated")
+	excludeFile := flag.String("exclude-file", "", "Never probe the IPs, ranges and CIDRs listed in a file")
+	dnsServer := flag.String("dns-server", "", "Resolve hostnames with this DNS server (host[:port]) instead of the system resolver")
+	hostsFile := flag.String("hosts-file", "", "Resolve the names in this hosts file to the addresses given there instead of using DNS")
+	resolveTimeout := flag.Int("resolve-timeout", int(ping.DefaultResolveTimeout/time.Millisecond), "Timeout in milliseconds for resolving a hostname")
+	keyFile := flag.String("hmac-key-file", "", "Tag every ICMP probe with an HMAC made with the key in this file and flag replies without a valid tag as spoofed")
+	orderName := flag.String("order", "sequential", "Order in which targets are probed: sequential, random or interleave (spread across /24 subnets)")
+	seed := flag.Uint64("seed", 0, "Seed for -order random, a random seed is used if 0")
+	udp := flag.Bool("udp", false, "Probe with UDP datagrams instead of ICMP echo")
+	udpPort := flag.Int("port", ping.DefaultUDPPort, "Destination port for UDP probes")
+	udpPayload := flag.String("payload", "", "Hex-encoded payload for UDP probes (e.g. a DNS or NTP request)")
+	httpMethod := flag.String("http-method", "GET", "Request method for http:// and https:// targets (GET or HEAD)")
+	certWarnDays := flag.Int("cert-warn-days", ping.DefaultCertWarnDays, "Warn when a tls:// target's certificate expires within this many days")
+	reachable := flag.Int("reachable", 0, "Exit with status 0 if at least this many targets are alive, even if others are not")
+	reachablePct := flag.Float64("reachable-pct", 0, "Exit with status 0 if at least this percentage of targets is alive, even if others are not")
+	maxLoss := flag.String("max-loss", "", "Fail if a target loses more than this percentage of pings, e.g. 1%")
+	maxAvg := flag.String("max-avg", "", "Fail if the average RTT of a target is above this, e.g. 20ms")
+	maxP95 := flag.String("max-p95", "", "Fail if the 95th percentile RTT of a target is above this, e.g. 50ms")
+	maxJitter := flag.String("max-jitter", "", "Fail if the jitter of a target is above this, e.g. 5ms")
+	assertGroups := flag.Bool("assert-groups", false, "With -m, check -max-* against every hostname instead of each of its addresses")
+	junitFile := flag.String("junit", "", "Write a JUnit XML report with one testcase per target to this file")
+
+	// Invalid arguments exit with status 3 like fping rather than the flag package's 2
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(exitAlive)
+		}
+		os.Exit(exitUsage)
+	}
+
+	if *reachable < 0 || *reachablePct < 0 || *reachablePct > 100 {
+		fmt.Println("Error: -reachable must not be negative and -reachable-pct must be between 0 and 100")
+		os.Exit(exitUsage)
+	}
+
+	method := strings.ToUpper(*httpMethod)
+	if method != "GET" && method != "HEAD" {
+		fmt.Println("Error: -http-method must be GET or HEAD")
+		os.Exit(exitUsage)
+	}
+
+	payload, err := hex.DecodeString(*udpPayload)
+	if err != nil {
+		fmt.Printf("Error: Invalid -payload, expected hex bytes: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	order, err := target.ParseOrder(*orderName)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
 
-	flag.Parse()
+	var assertions []ping.Assertion
+	for _, limit := range []struct {
+		stat  ping.Stat
+		value string
+	}{
+		{ping.StatLoss, *maxLoss},
+		{ping.StatAvg, *maxAvg},
+		{ping.StatP95, *maxP95},
+		{ping.StatJitter, *maxJitter},
+	} {
+		if limit.value == "" {
+			continue
+		}
+		assertion, err := ping.ParseAssertion(limit.stat, limit.value)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		assertions = append(assertions, assertion)
+	}
+
+	if *countReport > 0 {
+		*count = *countReport
+	}
 
 	if *aliveOnly && *unreachableOnly {
 		fmt.Println("Error: Cannot use both -a and -u options simultaneously")
-		os.Exit(1)
-	}
-
-	var targets []string
-	var err error
-
-	// Handle target input
-	if *cidrOrRange != "" {
-		// CIDR notation
-		if strings.Contains(*cidrOrRange, "/") {
-			targets, err = target.GenerateFromCIDR(*cidrOrRange)
-			if err != nil {
-				fmt.Printf("Error generating targets from CIDR %s: %v\n", *cidrOrRange, err)
-				os.Exit(1)
-			}
-		} else if strings.Contains(*cidrOrRange, "-") {
-			// IP range with dash notation (e.g., 192.168.1.1-192.168.1.10)
-			parts := strings.Split(*cidrOrRange, "-")
-			if len(parts) != 2 {
-				fmt.Println("Error: Invalid IP range format. Use format: start-end (e.g., 192.168.1.1-192.168.1.10)")
-				os.Exit(1)
-			}
-			targets, err = target.GenerateFromRange(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
-			if err != nil {
-				fmt.Printf("Error generating targets from range %s: %v\n", *cidrOrRange, err)
-				os.Exit(1)
-			}
-		} else {
-			fmt.Println("Error: -g requires either CIDR notation (x.x.x.x/y) or IP range (start-end)")
-			os.Exit(1)
+		os.Exit(exitUsage)
+	}
+
+	// Plain targets are pinged with ICMP unless another probe type is chosen
+	scheme := ping.DefaultScheme
+	if *udp {
+		scheme = "udp"
+	}
+
+	var targets []target.Spec
+
+	// Handle target input. Every source goes through the same spec parser and
+	// the sources can be combined, stdin is only read if no other one is used.
+	for _, value := range generate {
+		spec, err := target.Parse(value)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitUsage)
 		}
-	} else if *inputFile != "" {
+		if spec.Kind == target.KindHost {
+			fmt.Println("Error: -g requires CIDR notation (x.x.x.x/y), an IP range (start-end) or an octet pattern (10.0-3.1-254.1)")
+			os.Exit(exitUsage)
+		}
+		targets = append(targets, spec)
+	}
+	if *inputFile != "" {
 		// Read targets from file
-		targets, err = target.ReadFromFile(*inputFile)
+		fileTargets, err := target.ReadFromFile(*inputFile)
 		if err != nil {
 			fmt.Printf("Error reading target file: %v\n", err)
-			os.Exit(1)
+			os.Exit(readErrorCode(err))
 		}
-	} else if len(flag.Args()) > 0 {
+		targets = append(targets, fileTargets...)
+	}
+	if len(flag.Args()) > 0 {
 		// Use command line arguments as targets
-		targets = flag.Args()
-	} else {
+		argTargets, err := target.ParseAll(flag.Args())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		targets = append(targets, argTargets...)
+	}
+	if len(targets) == 0 {
 		// Check if there's data on stdin
 		stdinTargets, err := target.ReadFromStdin()
 		if err != nil {
 			fmt.Printf("Error reading from stdin: %v\n", err)
-			os.Exit(1)
+			os.Exit(readErrorCode(err))
 		}
 		
 		if len(stdinTargets) > 0 {
@@ -96,7 +182,61 @@ func main() {
 			fmt.Println("Error: No targets specified")
 			fmt.Println("Usage: goping [options] <target1> <target2> ...")
 			flag.PrintDefaults()
-			os.Exit(1)
+			os.Exit(exitUsage)
+		}
+	}
+
+	excludeList, err := target.NewExcludeList(excludes)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if *excludeFile != "" {
+		if err := excludeList.AddFile(*excludeFile); err != nil {
+			fmt.Printf("Error reading exclude file: %v\n", err)
+			os.Exit(readErrorCode(err))
+		}
+	}
+
+	// Networks and ranges wherever they came from are expanded lazily while
+	// pinging, duplicates and excluded addresses are skipped on the way
+	set, err := target.NewSet(targets)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	set.Exclude(excludeList)
+	set.SetOrder(order, *seed)
+
+	// Resolve every hostname once up front, answers are cached until their TTL expires
+	resolver := ping.NewResolver()
+	resolver.Server = *dnsServer
+	resolver.Timeout = time.Duration(*resolveTimeout) * time.Millisecond
+	if *hostsFile != "" {
+		if err := resolver.LoadHosts(*hostsFile); err != nil {
+			fmt.Printf("Error reading hosts file: %v\n", err)
+			os.Exit(readErrorCode(err))
+		}
+	}
+	var hosts []string
+	for _, spec := range targets {
+		if spec.Kind == target.KindHost {
+			hosts = append(hosts, spec.Host)
+		}
+	}
+	resolver.ResolveAll(hosts)
+
+	var key []byte
+	if *keyFile != "" {
+		key, err = os.ReadFile(*keyFile)
+		if err != nil {
+			fmt.Printf("Error reading key file: %v\n", err)
+			os.Exit(exitSystem)
+		}
+		key = bytes.TrimSpace(key)
+		if len(key) == 0 {
+			fmt.Println("Error: The key file is empty")
+			os.Exit(exitUsage)
 		}
 	}
 
@@ -110,13 +250,64 @@ func main() {
 		UnreachableOnly: *unreachableOnly,
 		Quiet:           *quiet,
 		ShowStats:       *showStats,
+		DefaultScheme:   scheme,
+		UDPPort:         *udpPort,
+		UDPPayload:      payload,
+		HTTPMethod:      method,
+		CertWarnDays:    *certWarnDays,
+		AllAddresses:    *allAddresses,
+		ShowNames:       *showNames,
+		ShowAddresses:   *showAddresses,
+		Resolver:        resolver,
+		Exclude:         excludeList,
+		Set:             set,
+		Key:             key,
+		CountReport:     *countReport > 0,
+		Elapsed:         *elapsed,
+		Timestamps:      *timestamps,
+		Assertions:      assertions,
+		AssertGroups:    *assertGroups,
+		KeepResults:     *junitFile != "",
+	}
+
+	pinger := ping.NewPinger(set.All(), pingerConfig)
+
+	// Check for administrator privileges, only raw ICMP sockets need them
+	if pinger.NeedsRawSocket(targets) && !ping.IsAdmin() {
+		fmt.Println("GoPing requires administrator privileges to send ICMP packets")
+		fmt.Println("Please run this program as an administrator")
+		os.Exit(exitSystem)
 	}
 
 	// Run the pinger
-	pinger := ping.NewPinger(targets, pingerConfig)
 	err = pinger.Run()
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		if errors.Is(err, ping.ErrUnknownScheme) {
+			os.Exit(exitUsage)
+		}
+		os.Exit(exitSystem)
+	}
+
+	if *junitFile != "" {
+		if err := writeJUnit(pinger, *junitFile); err != nil {
+			fmt.Printf("Error writing JUnit report: %v\n", err)
+			os.Exit(exitSystem)
+		}
+	}
+
+	os.Exit(exitCode(pinger.Totals(), *reachable, *reachablePct))
+}
+
+// writeJUnit writes the JUnit XML report of pinger to filename
+func writeJUnit(pinger *ping.Pinger, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := pinger.WriteJUnit(file); err != nil {
+		file.Close()
+		return err
 	}
-} 
\ No newline at end of file
+	return file.Close()
+}
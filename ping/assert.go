@@ -0,0 +1,223 @@
+package ping
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stat is a statistic of a target that an assertion can limit
+type Stat string
+
+// Statistics assertions can limit
+const (
+	StatLoss   Stat = "loss"
+	StatAvg    Stat = "avg"
+	StatP95    Stat = "p95"
+	StatJitter Stat = "jitter"
+)
+
+// Assertion is an upper limit on one statistic of every target, e.g. for
+// failing a CI job when a network change makes latency worse
+type Assertion struct {
+	Stat Stat
+	// MaxLoss is the highest packet loss in percent allowed by StatLoss
+	MaxLoss float64
+	// MaxRTT is the highest value allowed by the other statistics
+	MaxRTT time.Duration
+}
+
+// ParseAssertion parses the limit of an assertion on stat. Loss is given in
+// percent, e.g. "1%" or "0.5", RTTs as a duration, e.g. "20ms", or in
+// milliseconds.
+func ParseAssertion(stat Stat, value string) (Assertion, error) {
+	assertion := Assertion{Stat: stat}
+	if stat == StatLoss {
+		loss, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || math.IsNaN(loss) || loss < 0 || loss > 100 {
+			return Assertion{}, fmt.Errorf("invalid max-%s %q, expected a percentage", stat, value)
+		}
+		assertion.MaxLoss = loss
+		return assertion, nil
+	}
+
+	if ms, err := strconv.ParseFloat(value, 64); err == nil {
+		if math.IsInf(ms, 0) || math.IsNaN(ms) || ms < 0 {
+			return Assertion{}, fmt.Errorf("invalid max-%s %q, expected a duration", stat, value)
+		}
+		assertion.MaxRTT = time.Duration(ms * float64(time.Millisecond))
+		return assertion, nil
+	}
+	rtt, err := time.ParseDuration(value)
+	if err != nil || rtt < 0 {
+		return Assertion{}, fmt.Errorf("invalid max-%s %q, expected a duration", stat, value)
+	}
+	assertion.MaxRTT = rtt
+	return assertion, nil
+}
+
+// String returns the assertion the way it is given on the command line
+func (a Assertion) String() string {
+	if a.Stat == StatLoss {
+		return fmt.Sprintf("max-%s %g%%", a.Stat, a.MaxLoss)
+	}
+	return fmt.Sprintf("max-%s %v", a.Stat, a.MaxRTT)
+}
+
+// AssertionFailure is an assertion a target or group did not meet
+type AssertionFailure struct {
+	// Target is the name of the target, or of the group with AssertGroups
+	Target    string
+	Assertion Assertion
+	// Value is the statistic the assertion was checked against
+	Value string
+	// results are the results the statistic was taken from, the target's
+	// own or every address of the group
+	results []*Result
+}
+
+// String describes the failure, e.g. "10.0.0.1 : FAILED max-loss 1%, loss 5.0%"
+func (f AssertionFailure) String() string {
+	return fmt.Sprintf("%s : FAILED %s, %s %s", f.Target, f.Assertion, f.Assertion.Stat, f.Value)
+}
+
+// sample holds the probes of a target, or of every address of a group
+type sample struct {
+	sent     int
+	received int
+	// series holds the RTTs of each target separately, jitter is only
+	// measured between replies of the same target
+	series [][]time.Duration
+}
+
+// sampleOf returns the sample of the given results
+func sampleOf(results ...*Result) sample {
+	var s sample
+	for _, result := range results {
+		s.sent += result.Sent
+		s.received += result.Received
+		s.series = append(s.series, result.RTTs)
+	}
+	return s
+}
+
+// check returns the value of the statistic limited by a and whether it is
+// over the limit. RTT limits fail a sample without replies.
+func (s sample) check(a Assertion) (string, bool) {
+	if a.Stat == StatLoss {
+		loss := 100.0
+		if s.sent > 0 {
+			loss = float64(s.sent-s.received) / float64(s.sent) * 100
+		}
+		return fmt.Sprintf("%0.1f%%", loss), loss > a.MaxLoss
+	}
+	if s.received == 0 {
+		return "unknown, no replies", true
+	}
+	value := s.rtt(a.Stat)
+	return value.String(), value > a.MaxRTT
+}
+
+// rtt returns an RTT statistic of a sample with at least one reply
+func (s sample) rtt(stat Stat) time.Duration {
+	switch stat {
+	case StatAvg:
+		var sum time.Duration
+		for _, rtts := range s.series {
+			for _, rtt := range rtts {
+				sum += rtt
+			}
+		}
+		return sum / time.Duration(s.received)
+	case StatP95:
+		return percentile(slices.Concat(s.series...), 95)
+	case StatJitter:
+		return jitter(s.series)
+	}
+	return 0
+}
+
+// percentile returns the p-th percentile of rtts by the nearest-rank method
+func percentile(rtts []time.Duration, p float64) time.Duration {
+	sorted := slices.Clone(rtts)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+// jitter returns the mean difference between consecutive RTTs of each series
+func jitter(series [][]time.Duration) time.Duration {
+	var sum time.Duration
+	n := 0
+	for _, rtts := range series {
+		for i := 1; i < len(rtts); i++ {
+			diff := rtts[i] - rtts[i-1]
+			if diff < 0 {
+				diff = -diff
+			}
+			sum += diff
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / time.Duration(n)
+}
+
+// checkAssertions checks every assertion of the config against every kept
+// result, or against every group and the targets outside groups with
+// AssertGroups
+func (p *Pinger) checkAssertions() []AssertionFailure {
+	if len(p.config.Assertions) == 0 {
+		return nil
+	}
+
+	type checked struct {
+		name    string
+		results []*Result
+	}
+	var samples []checked
+	grouped := make(map[string]bool)
+	if p.config.AssertGroups {
+		for _, group := range p.Groups() {
+			samples = append(samples, checked{name: group.Name, results: group.Results})
+			grouped[group.Name] = true
+		}
+	}
+	for _, result := range p.Results() {
+		if !grouped[result.Group] {
+			samples = append(samples, checked{name: result.Name(), results: []*Result{result}})
+		}
+	}
+
+	var failures []AssertionFailure
+	for _, s := range samples {
+		sample := sampleOf(s.results...)
+		for _, assertion := range p.config.Assertions {
+			if value, failed := sample.check(assertion); failed {
+				failures = append(failures, AssertionFailure{Target: s.name, Assertion: assertion, Value: value, results: s.results})
+			}
+		}
+	}
+	return failures
+}
+
+// Failures returns the assertions that failed in the run
+func (p *Pinger) Failures() []AssertionFailure {
+	return p.failures
+}
+
+// printFailures prints the assertions that failed
+func (p *Pinger) printFailures() {
+	if len(p.failures) == 0 {
+		return
+	}
+	fmt.Println("\n--- Failed assertions ---")
+	for _, failure := range p.failures {
+		fmt.Println(failure)
+	}
+}
@@ -0,0 +1,107 @@
+package ping
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseAssertion(t *testing.T) {
+	tests := []struct {
+		stat     Stat
+		value    string
+		expected Assertion
+		wantErr  bool
+	}{
+		{stat: StatLoss, value: "1%", expected: Assertion{Stat: StatLoss, MaxLoss: 1}},
+		{stat: StatLoss, value: "0.5", expected: Assertion{Stat: StatLoss, MaxLoss: 0.5}},
+		{stat: StatLoss, value: "120%", wantErr: true},
+		{stat: StatLoss, value: "NaN", wantErr: true},
+		{stat: StatAvg, value: "20ms", expected: Assertion{Stat: StatAvg, MaxRTT: 20 * time.Millisecond}},
+		{stat: StatP95, value: "50", expected: Assertion{Stat: StatP95, MaxRTT: 50 * time.Millisecond}},
+		{stat: StatJitter, value: "fast", wantErr: true},
+		{stat: StatAvg, value: "Inf", wantErr: true},
+		{stat: StatP95, value: "-Inf", wantErr: true},
+		{stat: StatJitter, value: "NaN", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseAssertion(test.stat, test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseAssertion(%s, %q) error = %v, wantErr %v", test.stat, test.value, err, test.wantErr)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("ParseAssertion(%s, %q) = %+v, want %+v", test.stat, test.value, got, test.expected)
+		}
+	}
+}
+
+func TestPingerAssertions(t *testing.T) {
+	// Every target answers every other probe, after 2ms and 4ms
+	assertions := []Assertion{
+		{Stat: StatLoss, MaxLoss: 50},
+		{Stat: StatAvg, MaxRTT: 2 * time.Millisecond},
+		{Stat: StatP95, MaxRTT: 4 * time.Millisecond},
+		{Stat: StatJitter, MaxRTT: time.Millisecond},
+	}
+	config := Config{
+		Count:      4,
+		Timeout:    time.Second,
+		Assertions: assertions,
+	}
+	pinger := NewPinger(mustParseSpecs(t, "heartbeat://core-1;label=core-rtr"), config)
+	captureStdout(t, func() {
+		if err := pinger.Run(); err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	})
+
+	results := pinger.Results()
+	expected := []AssertionFailure{
+		{Target: "heartbeat://core-1 [core-rtr]", Assertion: assertions[1], Value: "3ms", results: results},
+		{Target: "heartbeat://core-1 [core-rtr]", Assertion: assertions[3], Value: "2ms", results: results},
+	}
+	if got := pinger.Failures(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Failures() = %+v, want %+v", got, expected)
+	}
+	if got := pinger.Totals().Failed; got != 2 {
+		t.Errorf("Totals().Failed = %d, want 2", got)
+	}
+	if got := expected[0].String(); got != "heartbeat://core-1 [core-rtr] : FAILED max-avg 2ms, avg 3ms" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestAssertGroups(t *testing.T) {
+	group := &Group{Name: "www.example.com", Results: []*Result{
+		{Target: "192.0.2.1", Group: "www.example.com", Sent: 4, Received: 4, RTTs: []time.Duration{1, 1, 1, 1}},
+		{Target: "192.0.2.2", Group: "www.example.com", Sent: 4, Received: 2, RTTs: []time.Duration{1, 1}, index: 1},
+	}}
+	assertions := []Assertion{{Stat: StatLoss, MaxLoss: 30}}
+
+	tests := []struct {
+		name     string
+		groups   bool
+		expected []AssertionFailure
+	}{
+		{
+			name:     "Per address",
+			expected: []AssertionFailure{{Target: "192.0.2.2 (www.example.com)", Assertion: assertions[0], Value: "50.0%", results: group.Results[1:]}},
+		},
+		{
+			name:   "Per group",
+			groups: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewPinger(nil, Config{Assertions: assertions, AssertGroups: test.groups})
+			p.results = group.Results
+			if got := p.checkAssertions(); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("checkAssertions() = %+v, want %+v", got, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package ping
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsTypes maps the record types accepted in ?type= to their wire values
+var dnsTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"NS":    dnsmessage.TypeNS,
+	"PTR":   dnsmessage.TypePTR,
+	"SOA":   dnsmessage.TypeSOA,
+	"SRV":   dnsmessage.TypeSRV,
+	"TXT":   dnsmessage.TypeTXT,
+}
+
+// rcodeNames uses the mnemonics from RFC 1035 and dig rather than the Go names
+var rcodeNames = map[dnsmessage.RCode]string{
+	dnsmessage.RCodeSuccess:        "NOERROR",
+	dnsmessage.RCodeFormatError:    "FORMERR",
+	dnsmessage.RCodeServerFailure:  "SERVFAIL",
+	dnsmessage.RCodeNameError:      "NXDOMAIN",
+	dnsmessage.RCodeNotImplemented: "NOTIMP",
+	dnsmessage.RCodeRefused:        "REFUSED",
+}
+
+func init() {
+	Register("dns", func() Prober { return &DNSProbe{} })
+}
+
+// DNSProbe sends a real query to a DNS server and measures how long the answer takes
+type DNSProbe struct {
+	// Resolver resolves the name of the server, the system resolver if nil
+	Resolver *Resolver
+}
+
+// Prepare takes the resolver from config
+func (d *DNSProbe) Prepare(config Config) error {
+	d.Resolver = config.Resolver
+	return nil
+}
+
+// Close does nothing, every query uses its own socket
+func (d *DNSProbe) Close() error {
+	return nil
+}
+
+// parseDNSTarget splits dns://server[:port]/name?type=A into its parts
+func parseDNSTarget(target string) (server string, name string, qtype dnsmessage.Type, err error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	server = u.Host
+	if server == "" {
+		return "", "", 0, fmt.Errorf("missing DNS server in %s", target)
+	}
+	if u.Port() == "" {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+
+	name = strings.Trim(u.Path, "/")
+	if name == "" {
+		return "", "", 0, fmt.Errorf("missing query name in %s", target)
+	}
+
+	typeName := strings.ToUpper(u.Query().Get("type"))
+	if typeName == "" {
+		typeName = "A"
+	}
+	qtype, ok := dnsTypes[typeName]
+	if !ok {
+		return "", "", 0, fmt.Errorf("unsupported query type %s", typeName)
+	}
+
+	return server, name, qtype, nil
+}
+
+// Probe sends a single query described by a dns:// target. Every answer counts
+// as alive, the rcode tells NXDOMAIN and SERVFAIL apart from real answers.
+func (d *DNSProbe) Probe(ctx context.Context, target string, seq int) Outcome {
+	server, name, qtype, err := parseDNSTarget(target)
+	if err != nil {
+		return Outcome{Err: err}
+	}
+	server, err = d.Resolver.resolveAddress(ctx, server)
+	if err != nil {
+		if err := resolveError(ctx, err); err != nil {
+			return Outcome{Err: err}
+		}
+		return Outcome{Status: StatusUnreachable}
+	}
+
+	start := time.Now()
+	msg, transport, err := exchangeDNS(ctx, server, name, qtype)
+	rtt := time.Since(start)
+	if err != nil {
+		if err := resolveError(ctx, err); err != nil {
+			return Outcome{Err: err}
+		}
+		// A closed port on the server is as unreachable as a silent one
+		var netErr net.Error
+		if (errors.As(err, &netErr) && netErr.Timeout()) || isPortUnreachable(err) {
+			return Outcome{Status: StatusUnreachable}
+		}
+		return Outcome{Err: err}
+	}
+
+	rcode := rcodeName(msg.Header.RCode)
+	return Outcome{
+		Status: StatusAlive,
+		RTT:    rtt,
+		Reply:  fmt.Sprintf("%s, %d answers over %s", rcode, len(msg.Answers), transport),
+		Rcode:  rcode,
+	}
+}
+
+// rcodeName returns the mnemonic for rcode
+func rcodeName(rcode dnsmessage.RCode) string {
+	if name, ok := rcodeNames[rcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// exchangeDNS sends a query over UDP and repeats it over TCP when the answer
+// comes back truncated. It returns the answer and the transport that carried it.
+func exchangeDNS(ctx context.Context, server string, name string, qtype dnsmessage.Type) (*dnsmessage.Message, string, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	id := uint16(rand.Uint32())
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: qname, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// A zero deadline means no deadline at all
+	deadline, _ := ctx.Deadline()
+	msg, err := exchangeUDP(server, packed, id, deadline)
+	if err != nil {
+		return nil, "", err
+	}
+	if !msg.Header.Truncated {
+		return msg, "udp", nil
+	}
+
+	msg, err = exchangeTCP(server, packed, id, deadline)
+	if err != nil {
+		return nil, "", err
+	}
+	return msg, "tcp", nil
+}
+
+// exchangeUDP sends query in a single datagram and waits for the matching answer
+func exchangeUDP(server string, query []byte, id uint16, deadline time.Time) (*dnsmessage.Message, error) {
+	conn, err := net.Dial("udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, 1232)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+
+		// Ignore stray datagrams, e.g. late answers to an earlier query
+		var msg dnsmessage.Message
+		if err := msg.Unpack(buffer[:n]); err != nil || msg.Header.ID != id || !msg.Header.Response {
+			continue
+		}
+		return &msg, nil
+	}
+}
+
+// exchangeTCP sends query with the two byte length prefix used by DNS over TCP
+func exchangeTCP(server string, query []byte, id uint16, deadline time.Time) (*dnsmessage.Message, error) {
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.Dial("tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	framed := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(framed, uint16(len(query)))
+	copy(framed[2:], query)
+	if _, err := conn.Write(framed); err != nil {
+		return nil, err
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	buffer := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, buffer); err != nil {
+		return nil, err
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(buffer); err != nil {
+		return nil, err
+	}
+	if msg.Header.ID != id {
+		return nil, fmt.Errorf("DNS answer ID %d does not match query ID %d", msg.Header.ID, id)
+	}
+	return &msg, nil
+}
+
+// summarizeRcodes formats how often each response code was seen, NOERROR first
+func summarizeRcodes(rcodes map[string]int) string {
+	names := make([]string, 0, len(rcodes))
+	for name := range rcodes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "NOERROR") != (names[j] == "NOERROR") {
+			return names[i] == "NOERROR"
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s x%d", name, rcodes[name]))
+	}
+	return "rcodes " + strings.Join(parts, ", ")
+}
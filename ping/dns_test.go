@@ -0,0 +1,269 @@
+package ping
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsTestServer is a stand-in DNS server listening on UDP and TCP on the same port
+type dnsTestServer struct {
+	udp  *net.UDPConn
+	tcp  *net.TCPListener
+	addr string
+	// queries counts the queries received over UDP
+	queries atomic.Int32
+	// delay holds back every answer over UDP by this many nanoseconds
+	delay atomic.Int64
+}
+
+// answerDNS builds the stand-in answer for query. Over UDP, big.test. is
+// answered with the truncation bit set to force the TCP fallback. 192.0.2.9
+// has a PTR record.
+func answerDNS(query []byte, overTCP bool) []byte {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil || len(msg.Questions) != 1 {
+		return nil
+	}
+	question := msg.Questions[0]
+
+	reply := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: msg.Header.ID, Response: true},
+		Questions: msg.Questions,
+	}
+	switch question.Name.String() {
+	case "example.test.", "big.test.":
+		if question.Name.String() == "big.test." && !overTCP {
+			reply.Header.Truncated = true
+			break
+		}
+		reply.Answers = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}},
+		}}
+	case "9.2.0.192.in-addr.arpa.":
+		reply.Answers = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("slow.test.")},
+		}}
+	case "broken.test.":
+		reply.Header.RCode = dnsmessage.RCodeServerFailure
+	default:
+		reply.Header.RCode = dnsmessage.RCodeNameError
+	}
+
+	packed, err := reply.Pack()
+	if err != nil {
+		return nil
+	}
+	return packed
+}
+
+func newDNSTestServer(t *testing.T) *dnsTestServer {
+	t.Helper()
+
+	udp, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() error = %v", err)
+	}
+	tcp, err := net.ListenTCP("tcp4", net.TCPAddrFromAddrPort(udp.LocalAddr().(*net.UDPAddr).AddrPort()))
+	if err != nil {
+		udp.Close()
+		t.Skipf("cannot listen on TCP next to UDP: %v", err)
+	}
+	server := &dnsTestServer{udp: udp, tcp: tcp, addr: udp.LocalAddr().String()}
+
+	go func() {
+		buffer := make([]byte, 512)
+		for {
+			n, addr, err := udp.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			server.queries.Add(1)
+			reply := answerDNS(buffer[:n], false)
+			time.AfterFunc(time.Duration(server.delay.Load()), func() { udp.WriteToUDP(reply, addr) })
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var length [2]byte
+				if _, err := io.ReadFull(conn, length[:]); err != nil {
+					return
+				}
+				query := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, query); err != nil {
+					return
+				}
+				reply := answerDNS(query, true)
+				framed := binary.BigEndian.AppendUint16(nil, uint16(len(reply)))
+				conn.Write(append(framed, reply...))
+			}()
+		}
+	}()
+
+	t.Cleanup(func() {
+		udp.Close()
+		tcp.Close()
+	})
+	return server
+}
+
+func TestDNSProbe(t *testing.T) {
+	server := newDNSTestServer(t)
+
+	tests := []struct {
+		name   string
+		target string
+		rcode  string
+		reply  string
+	}{
+		{
+			name:   "Answer",
+			target: "dns://" + server.addr + "/example.test?type=A",
+			rcode:  "NOERROR",
+			reply:  "NOERROR, 1 answers over udp",
+		},
+		{
+			name:   "Default query type",
+			target: "dns://" + server.addr + "/example.test",
+			rcode:  "NOERROR",
+			reply:  "NOERROR, 1 answers over udp",
+		},
+		{
+			name:   "Truncated answer falls back to TCP",
+			target: "dns://" + server.addr + "/big.test?type=A",
+			rcode:  "NOERROR",
+			reply:  "NOERROR, 1 answers over tcp",
+		},
+		{
+			name:   "NXDOMAIN",
+			target: "dns://" + server.addr + "/missing.test",
+			rcode:  "NXDOMAIN",
+			reply:  "NXDOMAIN, 0 answers over udp",
+		},
+		{
+			name:   "SERVFAIL",
+			target: "dns://" + server.addr + "/broken.test?type=aaaa",
+			rcode:  "SERVFAIL",
+			reply:  "SERVFAIL, 0 answers over udp",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			probe := &DNSProbe{}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			outcome := probe.Probe(ctx, test.target, 1)
+			if outcome.Err != nil {
+				t.Fatalf("Probe() error = %v", outcome.Err)
+			}
+			if outcome.Status != StatusAlive {
+				t.Errorf("Probe() status = %v, want %v", outcome.Status, StatusAlive)
+			}
+			if outcome.Rcode != test.rcode {
+				t.Errorf("Probe() rcode = %q, want %q", outcome.Rcode, test.rcode)
+			}
+			if outcome.Reply != test.reply {
+				t.Errorf("Probe() reply = %q, want %q", outcome.Reply, test.reply)
+			}
+		})
+	}
+}
+
+func TestDNSProbeUnresolved(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver *Resolver
+	}{
+		{name: "System resolver"},
+		{name: "Configured resolver", resolver: NewResolver()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			probe := &DNSProbe{Resolver: test.resolver}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			outcome := probe.Probe(ctx, "dns://missing.invalid/example.test", 1)
+			if !errors.Is(outcome.Err, ErrUnresolved) {
+				t.Errorf("Probe() = %+v, want an unresolved server", outcome)
+			}
+		})
+	}
+}
+
+func TestParseDNSTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		server  string
+		qname   string
+		qtype   dnsmessage.Type
+		wantErr bool
+	}{
+		{
+			name:   "Default port and type",
+			target: "dns://10.0.0.53/example.com",
+			server: "10.0.0.53:53",
+			qname:  "example.com",
+			qtype:  dnsmessage.TypeA,
+		},
+		{
+			name:   "Explicit port and type",
+			target: "dns://10.0.0.53:5353/example.com?type=MX",
+			server: "10.0.0.53:5353",
+			qname:  "example.com",
+			qtype:  dnsmessage.TypeMX,
+		},
+		{
+			name:    "Missing name",
+			target:  "dns://10.0.0.53/",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown type",
+			target:  "dns://10.0.0.53/example.com?type=BOGUS",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, qname, qtype, err := parseDNSTarget(test.target)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseDNSTarget() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if server != test.server || qname != test.qname || qtype != test.qtype {
+				t.Errorf("parseDNSTarget() = %s, %s, %v, want %s, %s, %v",
+					server, qname, qtype, test.server, test.qname, test.qtype)
+			}
+		})
+	}
+}
+
+func TestSummarizeRcodes(t *testing.T) {
+	rcodes := map[string]int{"SERVFAIL": 1, "NOERROR": 3, "NXDOMAIN": 2}
+	want := "rcodes NOERROR x3, NXDOMAIN x2, SERVFAIL x1"
+	if got := summarizeRcodes(rcodes); got != want {
+		t.Errorf("summarizeRcodes() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+package ping
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// sprintTM formats an RTT in milliseconds with as many decimals as fping's
+// sprint_tm, so that scripts parsing fping output can parse ours
+func sprintTM(rtt time.Duration) string {
+	t := float64(rtt) / float64(time.Millisecond)
+	switch {
+	case t < 0:
+		return fmt.Sprintf("%.2g", t)
+	case t < 1:
+		return fmt.Sprintf("%.3f", t)
+	case t < 10:
+		return fmt.Sprintf("%.2f", t)
+	case t < 100:
+		return fmt.Sprintf("%.1f", t)
+	case t < 1000000:
+		return fmt.Sprintf("%.0f", t)
+	}
+	return fmt.Sprintf("%.3e", t)
+}
+
+// timestamp returns the prefix fping -D puts in front of a line
+func timestamp(now time.Time) string {
+	return fmt.Sprintf("[%.5f] ", float64(now.UnixNano())/1e9)
+}
+
+// fpingOutput reports whether probes are reported in one of fping's formats
+func (p *Pinger) fpingOutput() bool {
+	return p.config.CountReport || p.config.Elapsed
+}
+
+// countMode reports whether probes are reported like fping -c or -C, with a
+// line for every probe and a report on stderr at the end. Like fping, -e
+// switches to it when more than one probe is sent to each target.
+func (p *Pinger) countMode() bool {
+	return p.config.CountReport || (p.config.Elapsed && p.config.Count > 1)
+}
+
+// linePrefix returns what goes in front of every per-probe line
+func (p *Pinger) linePrefix() string {
+	if p.config.Timestamps {
+		return timestamp(time.Now())
+	}
+	return ""
+}
+
+// printFpingProbe reports one probe like fping does. In count mode every
+// probe gets a line like with fping -c, e.g. "host : [0], 64 bytes, 0.123 ms
+// (0.123 avg, 0% loss)". Otherwise the first reply gets a line like fping -e,
+// e.g. "host is alive (0.123 ms)".
+func (p *Pinger) printFpingProbe(result *Result, seq int, outcome Outcome) {
+	alive := outcome.Status == StatusAlive
+	if p.config.Quiet || (p.config.AliveOnly && !alive) || (p.config.UnreachableOnly && alive) {
+		return
+	}
+	host := result.host()
+
+	if !p.countMode() {
+		if alive && result.Received == 1 {
+			fmt.Printf("%s%s is alive (%s ms)\n", p.linePrefix(), host, sprintTM(outcome.RTT))
+		}
+		return
+	}
+
+	// fping pads to the longest target, names looked up with ShowNames or
+	// ShowAddresses can only be measured once they are printed
+	p.width = max(p.width, len(host))
+
+	// fping counts probes from 0
+	var line strings.Builder
+	line.WriteString(p.linePrefix())
+	fmt.Fprintf(&line, "%-*s : [%d], ", p.width, host, seq-1)
+	if alive {
+		fmt.Fprintf(&line, "%d bytes, %s ms", outcome.Size, sprintTM(outcome.RTT))
+	} else {
+		line.WriteString("timed out")
+	}
+	if result.Received > 0 {
+		var sum time.Duration
+		for _, rtt := range result.RTTs {
+			sum += rtt
+		}
+		fmt.Fprintf(&line, " (%s avg, ", sprintTM(sum/time.Duration(result.Received)))
+	} else {
+		line.WriteString(" (NaN avg, ")
+	}
+	fmt.Fprintf(&line, "%d%% loss)", (result.Sent-result.Received)*100/result.Sent)
+	fmt.Println(line.String())
+}
+
+// printUnreachable reports a target that never answered like fping -e does
+func (p *Pinger) printUnreachable(result *Result) {
+	if !p.config.Elapsed || p.countMode() || p.config.Quiet || p.config.AliveOnly {
+		return
+	}
+	if result.Sent > 0 && result.Received == 0 {
+		fmt.Printf("%s%s is unreachable\n", p.linePrefix(), result.host())
+	}
+}
+
+// printCountReport writes a line per target like fping's count mode. With
+// CountReport it lists the RTT of every probe like fping -C, with "-" for
+// lost probes, otherwise the packet counts and RTTs like fping -c.
+func (p *Pinger) printCountReport(w io.Writer) {
+	results := p.Results()
+	width := p.width
+	for _, result := range results {
+		width = max(width, len(result.host()))
+	}
+
+	// fping separates the report from the probe lines, unless they are hidden
+	if !p.config.Quiet {
+		fmt.Fprintln(w)
+	}
+	for _, result := range results {
+		fmt.Fprintf(w, "%-*s :", width, result.host())
+		if p.config.CountReport {
+			for _, rtt := range result.History {
+				if rtt < 0 {
+					fmt.Fprint(w, " -")
+				} else {
+					fmt.Fprintf(w, " %s", sprintTM(rtt))
+				}
+			}
+			fmt.Fprintln(w)
+			continue
+		}
+
+		loss := 0
+		if result.Sent > 0 {
+			loss = (result.Sent - result.Received) * 100 / result.Sent
+		}
+		fmt.Fprintf(w, " xmt/rcv/%%loss = %d/%d/%d%%", result.Sent, result.Received, loss)
+		if result.Received > 0 {
+			s := sampleOf(result)
+			fmt.Fprintf(w, ", min/avg/max = %s/%s/%s", sprintTM(result.MinRTT), sprintTM(s.rtt(StatAvg)), sprintTM(result.MaxRTT))
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,159 @@
+package ping
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/windows-fping/goping/target"
+)
+
+// captureStdout returns what f prints to stdout
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	return string(out)
+}
+
+func TestSprintTM(t *testing.T) {
+	tests := []struct {
+		rtt      time.Duration
+		expected string
+	}{
+		{rtt: 123456 * time.Nanosecond, expected: "0.123"},
+		{rtt: 999999 * time.Nanosecond, expected: "1.000"},
+		{rtt: 1234567 * time.Nanosecond, expected: "1.23"},
+		{rtt: 12345678 * time.Nanosecond, expected: "12.3"},
+		{rtt: 123456789 * time.Nanosecond, expected: "123"},
+		{rtt: 2000 * time.Second, expected: "2.000e+06"},
+	}
+
+	for _, test := range tests {
+		if got := sprintTM(test.rtt); got != test.expected {
+			t.Errorf("sprintTM(%v) = %q, want %q", test.rtt, got, test.expected)
+		}
+	}
+}
+
+func TestFpingProbeLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{
+			name:   "Count report",
+			config: Config{CountReport: true},
+			expected: "10.0.0.1   : [0], 64 bytes, 1.50 ms (1.50 avg, 0% loss)\n" +
+				"10.0.0.1   : [1], timed out (1.50 avg, 50% loss)\n" +
+				"10.0.0.1   : [2], 64 bytes, 0.500 ms (1.00 avg, 33% loss)\n",
+		},
+		{
+			name:   "Elapsed with count",
+			config: Config{Elapsed: true, Count: 3},
+			expected: "10.0.0.1   : [0], 64 bytes, 1.50 ms (1.50 avg, 0% loss)\n" +
+				"10.0.0.1   : [1], timed out (1.50 avg, 50% loss)\n" +
+				"10.0.0.1   : [2], 64 bytes, 0.500 ms (1.00 avg, 33% loss)\n",
+		},
+		{
+			name:     "Elapsed",
+			config:   Config{Elapsed: true},
+			expected: "10.0.0.1 is alive (1.50 ms)\n",
+		},
+	}
+
+	outcomes := []Outcome{
+		{Status: StatusAlive, RTT: 1500 * time.Microsecond, Size: 64},
+		{Status: StatusUnreachable},
+		{Status: StatusAlive, RTT: 500 * time.Microsecond, Size: 64},
+	}
+	// Lines are padded to the longest target of the set, even before it is probed
+	specs, err := target.ParseAll([]string{"10.0.0.1", "10.0.0.100"})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	set, err := target.NewSet(specs)
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.Set = set
+			p := NewPinger(set.All(), test.config)
+			result := &Result{Target: "10.0.0.1"}
+			got := captureStdout(t, func() {
+				for i, outcome := range outcomes {
+					p.recordOutcome(result, i+1, outcome)
+				}
+			})
+			if got != test.expected {
+				t.Errorf("output = %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestPrintCountReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{
+			name:   "Count report",
+			config: Config{CountReport: true},
+			expected: "\n10.0.0.1         : 1.50 - 12.0\n" +
+				"host.example.com : - - -\n",
+		},
+		{
+			name:   "Quiet count report",
+			config: Config{CountReport: true, Quiet: true},
+			expected: "10.0.0.1         : 1.50 - 12.0\n" +
+				"host.example.com : - - -\n",
+		},
+		{
+			name:   "Elapsed with count",
+			config: Config{Elapsed: true, Count: 3},
+			expected: "\n10.0.0.1         : xmt/rcv/%loss = 3/2/33%, min/avg/max = 1.50/6.75/12.0\n" +
+				"host.example.com : xmt/rcv/%loss = 3/0/100%\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewPinger(nil, test.config)
+			p.results = []*Result{
+				{
+					Target: "10.0.0.1", Sent: 3, Received: 2,
+					RTTs:    []time.Duration{1500 * time.Microsecond, 12 * time.Millisecond},
+					MinRTT:  1500 * time.Microsecond,
+					MaxRTT:  12 * time.Millisecond,
+					History: []time.Duration{1500 * time.Microsecond, -1, 12 * time.Millisecond},
+					index:   0,
+				},
+				{Target: "host.example.com", Sent: 3, History: []time.Duration{-1, -1, -1}, index: 1},
+			}
+
+			var out bytes.Buffer
+			p.printCountReport(&out)
+			if out.String() != test.expected {
+				t.Errorf("printCountReport() = %q, want %q", out.String(), test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package ping
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/windows-fping/goping/target"
+)
+
+// nameSchemes are the probe types that send the hostname itself, in the
+// Host header or as TLS server name, so they are never expanded to addresses
+var nameSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+	"tls":   true,
+}
+
+// Group collects the results of every address a hostname resolved to
+type Group struct {
+	Name    string
+	Results []*Result
+}
+
+// Alive returns the number of addresses that answered at least once
+func (g *Group) Alive() int {
+	alive := 0
+	for _, result := range g.Results {
+		if result.Received > 0 {
+			alive++
+		}
+	}
+	return alive
+}
+
+// AnyAlive reports whether at least one address of the name answered
+func (g *Group) AnyAlive() bool {
+	return g.Alive() > 0
+}
+
+// AllAlive reports whether every address of the name answered
+func (g *Group) AllAlive() bool {
+	return g.Alive() == len(g.Results)
+}
+
+// State describes the aggregate state of the name
+func (g *Group) State() string {
+	switch {
+	case g.AllAlive():
+		return "all alive"
+	case g.AnyAlive():
+		return "some alive"
+	}
+	return "none alive"
+}
+
+// Groups returns the kept results of every hostname expanded with
+// AllAddresses, in the order the names were given
+func (p *Pinger) Groups() []*Group {
+	var groups []*Group
+	byName := make(map[string]*Group)
+	for _, result := range p.Results() {
+		if result.Group == "" {
+			continue
+		}
+		group := byName[result.Group]
+		if group == nil {
+			group = &Group{Name: result.Group}
+			byName[result.Group] = group
+			groups = append(groups, group)
+		}
+		group.Results = append(group.Results, result)
+	}
+	return groups
+}
+
+// expand returns one spec per address of spec's hostname if AllAddresses is
+// set. Addresses, names that fail to resolve and probe types that need the
+// name are returned as they are, and errors are left to the prober. Excluded
+// addresses are dropped, and so are targets that are probed already, either
+// as a target of the set or for another name.
+func (p *Pinger) expand(spec target.Spec) []target.Spec {
+	if !p.config.AllAddresses || spec.Host == "" || net.ParseIP(spec.Host) != nil || nameSchemes[p.schemeOf(spec)] {
+		return []target.Spec{spec}
+	}
+
+	ips, err := p.config.Resolver.Lookup(context.Background(), spec.Host)
+	if err != nil || len(ips) == 0 {
+		return []target.Spec{spec}
+	}
+
+	specs := make([]target.Spec, 0, len(ips))
+	for _, ip := range ips {
+		expanded := withHost(spec, ip.String())
+		if p.config.Exclude.Contains(ip) || p.expanded[expanded.Target] {
+			continue
+		}
+		if p.config.Set != nil && p.config.Set.Contains(expanded.Target) {
+			continue
+		}
+		p.expanded[expanded.Target] = true
+		specs = append(specs, expanded)
+	}
+	return specs
+}
+
+// withHost returns spec with its host replaced by addr, keeping the scheme,
+// port and path
+func withHost(spec target.Spec, addr string) target.Spec {
+	prefix, rest := "", spec.Target
+	if i := strings.Index(rest, "://"); i > 0 {
+		prefix, rest = rest[:i+3], rest[i+3:]
+	}
+	if i := strings.Index(strings.ToLower(rest), strings.ToLower(spec.Host)); i >= 0 {
+		rest = rest[:i] + addr + rest[i+len(spec.Host):]
+	}
+	spec.Target = prefix + rest
+	spec.Host = addr
+	return spec
+}
+
+// printGroups prints the aggregate state of every expanded hostname
+func (p *Pinger) printGroups() {
+	groups := p.Groups()
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Println()
+	for _, group := range groups {
+		fmt.Printf("%s : %d/%d addresses alive, %s\n", group.Name, group.Alive(), len(group.Results), group.State())
+	}
+}
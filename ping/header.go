@@ -0,0 +1,76 @@
+package ping
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ReplyHeader holds the IP header fields of an echo reply
+type ReplyHeader struct {
+	TTL int
+	// ID is the IP identification field
+	ID  int
+	TOS int
+	// Size is the length of the whole datagram in bytes
+	Size int
+}
+
+// splitIPv4 splits a datagram read from a raw socket into its IP header and
+// ICMP message. Platforms that hand over the ICMP message alone get a nil
+// header, an ICMP message never starts with the IPv4 version nibble.
+func splitIPv4(b []byte) (*ReplyHeader, []byte) {
+	if len(b) < 20 || b[0]>>4 != 4 {
+		return nil, b
+	}
+	headerLen := int(b[0]&0x0f) << 2
+	if headerLen < 20 || headerLen > len(b) {
+		return nil, b
+	}
+	return &ReplyHeader{
+		TTL:  int(b[8]),
+		ID:   int(binary.BigEndian.Uint16(b[4:6])),
+		TOS:  int(b[1]),
+		Size: len(b),
+	}, b[headerLen:]
+}
+
+// initialTTLs are the initial TTLs common operating systems send with, and
+// the family each one usually points to
+var initialTTLs = []struct {
+	ttl    int
+	family string
+}{
+	{64, "Linux/Unix"},
+	{128, "Windows"},
+	{255, "network device"},
+}
+
+// GuessOrigin returns the hop count and OS family implied by a reply TTL,
+// assuming the sender started from the nearest common initial TTL above it
+func GuessOrigin(ttl int) (hops int, family string) {
+	for _, initial := range initialTTLs {
+		if ttl <= initial.ttl {
+			return initial.ttl - ttl, initial.family
+		}
+	}
+	return 0, "unknown"
+}
+
+// summarizeTTL describes the TTLs of replies, e.g. "ttl 57, ~7 hops,
+// Linux/Unix", followed by every other TTL seen
+func summarizeTTL(headers []ReplyHeader) string {
+	last := headers[len(headers)-1].TTL
+	hops, family := GuessOrigin(last)
+	summary := fmt.Sprintf("ttl %d, ~%d hops, %s", last, hops, family)
+
+	changes := 0
+	for i := 1; i < len(headers); i++ {
+		if headers[i].TTL != headers[i-1].TTL {
+			changes++
+		}
+	}
+	if changes > 0 {
+		summary += fmt.Sprintf(", ttl changed %d times (first %d)", changes, headers[0].TTL)
+	}
+	return summary
+}
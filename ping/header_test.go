@@ -0,0 +1,73 @@
+package ping
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSplitIPv4(t *testing.T) {
+	message := []byte{0, 0, 0xab, 0xcd, 0, 1, 0, 1}
+	header := []byte{
+		0x45, 0x10, 0x00, 0x1c, 0x12, 0x34, 0x00, 0x00,
+		0x39, 0x01, 0x00, 0x00, 192, 0, 2, 1, 192, 0, 2, 2,
+	}
+
+	gotHeader, gotMessage := splitIPv4(append(header, message...))
+	expected := &ReplyHeader{TTL: 57, ID: 0x1234, TOS: 0x10, Size: 28}
+	if !reflect.DeepEqual(gotHeader, expected) {
+		t.Errorf("splitIPv4() header = %+v, want %+v", gotHeader, expected)
+	}
+	if !bytes.Equal(gotMessage, message) {
+		t.Errorf("splitIPv4() message = %x, want %x", gotMessage, message)
+	}
+
+	// Without an IP header the message is returned as it is
+	gotHeader, gotMessage = splitIPv4(message)
+	if gotHeader != nil || !bytes.Equal(gotMessage, message) {
+		t.Errorf("splitIPv4() = %+v, %x, want no header and the message", gotHeader, gotMessage)
+	}
+}
+
+func TestGuessOrigin(t *testing.T) {
+	tests := []struct {
+		ttl    int
+		hops   int
+		family string
+	}{
+		{ttl: 64, hops: 0, family: "Linux/Unix"},
+		{ttl: 57, hops: 7, family: "Linux/Unix"},
+		{ttl: 116, hops: 12, family: "Windows"},
+		{ttl: 250, hops: 5, family: "network device"},
+	}
+
+	for _, test := range tests {
+		hops, family := GuessOrigin(test.ttl)
+		if hops != test.hops || family != test.family {
+			t.Errorf("GuessOrigin(%d) = %d, %s, want %d, %s", test.ttl, hops, family, test.hops, test.family)
+		}
+	}
+}
+
+func TestSummarizeTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttls     []int
+		expected string
+	}{
+		{name: "Stable", ttls: []int{57, 57}, expected: "ttl 57, ~7 hops, Linux/Unix"},
+		{name: "Route change", ttls: []int{57, 55, 55}, expected: "ttl 55, ~9 hops, Linux/Unix, ttl changed 1 times (first 57)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var headers []ReplyHeader
+			for _, ttl := range test.ttls {
+				headers = append(headers, ReplyHeader{TTL: ttl})
+			}
+			if got := summarizeTTL(headers); got != test.expected {
+				t.Errorf("summarizeTTL() = %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
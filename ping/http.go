@@ -0,0 +1,142 @@
+package ping
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"time"
+)
+
+// HTTPTiming breaks a single HTTP probe down into its phases
+type HTTPTiming struct {
+	DNS        time.Duration
+	Connect    time.Duration
+	TLS        time.Duration
+	FirstByte  time.Duration
+	StatusCode int
+}
+
+// String formats the timing the way it is shown next to each probe
+func (t HTTPTiming) String() string {
+	return fmt.Sprintf("HTTP %d, dns %v, connect %v, tls %v, first byte %v",
+		t.StatusCode, t.DNS, t.Connect, t.TLS, t.FirstByte)
+}
+
+func init() {
+	Register("http", func() Prober { return &HTTPProbe{} })
+	Register("https", func() Prober { return &HTTPProbe{} })
+}
+
+// HTTPProbe requests a URL and records how long each phase of the request took
+type HTTPProbe struct {
+	Method string
+	// Resolver resolves the host of the URL, the system resolver if nil
+	Resolver *Resolver
+}
+
+// Prepare takes the request method and resolver from config
+func (h *HTTPProbe) Prepare(config Config) error {
+	h.Method = config.HTTPMethod
+	h.Resolver = config.Resolver
+	return nil
+}
+
+// Close does nothing, connections are never reused between probes
+func (h *HTTPProbe) Close() error {
+	return nil
+}
+
+// Probe sends a single request to url. Any HTTP response counts as alive, the
+// status code is recorded so that error pages can still be told apart.
+func (h *HTTPProbe) Probe(ctx context.Context, url string, seq int) Outcome {
+	method := h.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	// A fresh transport for every probe, otherwise only the first one would
+	// pay for DNS, connect and the TLS handshake
+	transport := &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DisableKeepAlives: true,
+		TLSClientConfig:   &tls.Config{},
+		DialContext:       h.Resolver.dialContext,
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{
+		Transport: transport,
+		// Measure the URL we were given, not wherever it redirects to
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	var timing HTTPTiming
+	var dnsStart, connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { timing.DNS = time.Since(dnsStart) },
+		ConnectStart:      func(string, string) { connectStart = time.Now() },
+		ConnectDone:       func(string, string, error) { timing.Connect = time.Since(connectStart) },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { timing.TLS = time.Since(tlsStart) },
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), method, url, nil)
+	if err != nil {
+		return Outcome{Err: err}
+	}
+	req.Header.Set("User-Agent", "goping")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		if err := resolveError(ctx, err); err != nil {
+			return Outcome{Err: err}
+		}
+		return Outcome{Status: StatusUnreachable}
+	}
+	timing.FirstByte = time.Since(start)
+	timing.StatusCode = resp.StatusCode
+
+	// Drain a little of the body so the server sees a well-behaved client
+	io.CopyN(io.Discard, resp.Body, 64*1024)
+	resp.Body.Close()
+
+	return Outcome{
+		Status: StatusAlive,
+		RTT:    timing.FirstByte,
+		Reply:  timing.String(),
+		HTTP:   &timing,
+	}
+}
+
+// summarizeHTTP formats the status codes and average phase timings of a target
+func summarizeHTTP(timings []HTTPTiming) string {
+	var dns, connect, tlsTime, firstByte time.Duration
+	counts := make(map[int]int)
+	var codes []int
+	for _, t := range timings {
+		dns += t.DNS
+		connect += t.Connect
+		tlsTime += t.TLS
+		firstByte += t.FirstByte
+		if counts[t.StatusCode] == 0 {
+			codes = append(codes, t.StatusCode)
+		}
+		counts[t.StatusCode]++
+	}
+
+	statuses := make([]string, 0, len(codes))
+	for _, code := range codes {
+		statuses = append(statuses, fmt.Sprintf("%d x%d", code, counts[code]))
+	}
+
+	n := time.Duration(len(timings))
+	return fmt.Sprintf("HTTP %s, dns/connect/tls/first byte = %v/%v/%v/%v",
+		strings.Join(statuses, ", "), dns/n, connect/n, tlsTime/n, firstByte/n)
+}
@@ -0,0 +1,102 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		statusCode int
+	}{
+		{
+			name:       "GET",
+			method:     http.MethodGet,
+			path:       "/",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "HEAD",
+			method:     http.MethodHead,
+			path:       "/",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Error status is still alive",
+			method:     http.MethodGet,
+			path:       "/missing",
+			statusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			probe := &HTTPProbe{Method: test.method}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			outcome := probe.Probe(ctx, server.URL+test.path, 1)
+			if outcome.Status != StatusAlive {
+				t.Fatalf("Probe() status = %v, want %v", outcome.Status, StatusAlive)
+			}
+			if outcome.HTTP == nil || outcome.HTTP.StatusCode != test.statusCode {
+				t.Errorf("Probe() timing = %+v, want status %d", outcome.HTTP, test.statusCode)
+			}
+			if outcome.RTT <= 0 || outcome.RTT != outcome.HTTP.FirstByte {
+				t.Errorf("Probe() RTT = %v, want first byte time %v", outcome.RTT, outcome.HTTP.FirstByte)
+			}
+		})
+	}
+}
+
+func TestHTTPProbeUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	probe := &HTTPProbe{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	outcome := probe.Probe(ctx, url, 1)
+	if outcome.Status != StatusUnreachable {
+		t.Errorf("Probe() status = %v, want %v", outcome.Status, StatusUnreachable)
+	}
+}
+
+func TestHTTPProbeUnresolved(t *testing.T) {
+	probe := &HTTPProbe{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	outcome := probe.Probe(ctx, "http://missing.invalid/", 1)
+	if !errors.Is(outcome.Err, ErrUnresolved) {
+		t.Errorf("Probe() = %+v, want an unresolved name", outcome)
+	}
+}
+
+func TestSummarizeHTTP(t *testing.T) {
+	timings := []HTTPTiming{
+		{DNS: 2 * time.Millisecond, Connect: 4 * time.Millisecond, FirstByte: 10 * time.Millisecond, StatusCode: 200},
+		{DNS: 4 * time.Millisecond, Connect: 6 * time.Millisecond, FirstByte: 20 * time.Millisecond, StatusCode: 503},
+		{DNS: 3 * time.Millisecond, Connect: 5 * time.Millisecond, FirstByte: 30 * time.Millisecond, StatusCode: 200},
+	}
+
+	want := "HTTP 200 x2, 503 x1, dns/connect/tls/first byte = 3ms/5ms/0s/20ms"
+	if got := summarizeHTTP(timings); got != want {
+		t.Errorf("summarizeHTTP() = %q, want %q", got, want)
+	}
+}
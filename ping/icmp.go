@@ -0,0 +1,277 @@
+package ping
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+
+	"github.com/windows-fping/goping/target"
+)
+
+func init() {
+	Register("icmp", func() Prober { return &ICMPProber{} })
+}
+
+// icmpKey identifies an outstanding echo request by target index and full
+// sequence number. The destination is not part of it, so that a reply from
+// another address, e.g. through NAT or anycast, still finds its probe.
+type icmpKey struct {
+	target uint32
+	seq    uint32
+}
+
+// icmpHeaderSize is the length of the ICMP echo header in front of the data
+const icmpHeaderSize = 8
+
+// icmpReply is what the listener hands to a waiting probe
+type icmpReply struct {
+	rtt    time.Duration
+	header *ReplyHeader
+	source string
+	size   int
+}
+
+// pendingProbe is an echo request waiting for its reply
+type pendingProbe struct {
+	reply chan icmpReply
+	// addr is the address the request was sent to
+	addr     string
+	answered bool
+	// spoofed counts replies with a missing or invalid tag in keyed mode
+	spoofed int
+}
+
+// Reasons an echo reply is unsolicited
+const (
+	reasonForeignID      = "echo ID of another process"
+	reasonForeignPayload = "payload of another program or an earlier run"
+	reasonNoProbe        = "no matching probe, late reply"
+	reasonDuplicate      = "duplicate reply"
+)
+
+// unsolicitedKey groups unsolicited replies in the report
+type unsolicitedKey struct {
+	source string
+	reason string
+}
+
+// ICMPProber sends ICMP echo requests over a raw socket. The socket's listener
+// hands every reply to the probe waiting for it. A raw socket sees the echo
+// replies of every process on the machine, so replies must match an
+// outstanding probe exactly and everything else is only counted.
+type ICMPProber struct {
+	socket *Socket
+	// ownSocket is set if the prober opened the socket and must close it
+	ownSocket   bool
+	resolver    *Resolver
+	exclude     *target.ExcludeList
+	id          int
+	session     uint32
+	key         []byte
+	pending     map[icmpKey]*pendingProbe
+	unsolicited map[unsolicitedKey]int
+	mutex       sync.Mutex
+}
+
+// Prepare joins the shared socket of config, or opens a socket of its own,
+// and picks a random echo ID and session token
+func (p *ICMPProber) Prepare(config Config) error {
+	p.resolver = config.Resolver
+	if p.resolver == nil {
+		p.resolver = NewResolver()
+	}
+	p.exclude = config.Exclude
+	p.pending = make(map[icmpKey]*pendingProbe)
+	p.key = config.Key
+	p.session = rand.Uint32()
+	p.unsolicited = make(map[unsolicitedKey]int)
+
+	p.socket = config.Socket
+	if p.socket == nil {
+		socket, err := OpenSocket()
+		if err != nil {
+			return err
+		}
+		p.socket = socket
+		p.ownSocket = true
+	}
+
+	var err error
+	p.id, err = p.socket.register(p)
+	if err != nil {
+		if p.ownSocket {
+			p.socket.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+// Close leaves the socket and closes it unless it is shared
+func (p *ICMPProber) Close() error {
+	p.socket.unregister(p.id)
+	if p.ownSocket {
+		return p.socket.Close()
+	}
+	return nil
+}
+
+// Probe sends a single echo request to target and waits for the reply
+func (p *ICMPProber) Probe(ctx context.Context, target string, seq int) Outcome {
+	// Resolve hostname to IP, normally answered from the cache
+	ips, err := p.resolver.Lookup(ctx, hostOf(target))
+	if err != nil {
+		// Running out of time while the name is resolved is a lost probe,
+		// not a name that does not resolve
+		if ctx.Err() != nil {
+			return Outcome{Status: StatusUnreachable}
+		}
+		return Outcome{Err: fmt.Errorf("%w: %w", ErrUnresolved, err)}
+	}
+	if p.exclude.Contains(ips[0]) {
+		return Outcome{Err: fmt.Errorf("%w %s", ErrExcluded, ips[0])}
+	}
+	ipAddr := &net.IPAddr{IP: ips[0]}
+
+	payload := echoPayload{
+		Session: p.session,
+		Target:  uint32(targetIndex(ctx)),
+		Seq:     uint32(seq),
+	}
+
+	// Register before sending so a fast reply cannot slip past. Only probes
+	// in flight are in the table, the reply itself carries the send time.
+	key := icmpKey{target: payload.Target, seq: payload.Seq}
+	probe := &pendingProbe{reply: make(chan icmpReply, 1), addr: ipAddr.IP.String()}
+	p.mutex.Lock()
+	p.pending[key] = probe
+	p.mutex.Unlock()
+	defer func() {
+		p.mutex.Lock()
+		delete(p.pending, key)
+		p.mutex.Unlock()
+	}()
+
+	payload.Sent = monotonicNow()
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Code: 0,
+		Body: &icmp.Echo{
+			ID:   p.id,
+			Seq:  seq & 0xffff,
+			Data: padPayload(signPayload(payload.marshal(), p.key)),
+		},
+	}
+	msgBytes, err := msg.Marshal(nil)
+	if err != nil {
+		return Outcome{Err: fmt.Errorf("error marshaling message: %w", err)}
+	}
+
+	err = p.socket.writeTo(msgBytes, ipAddr)
+	if err != nil {
+		return Outcome{Err: fmt.Errorf("error sending: %w", err)}
+	}
+
+	// Wait for response or timeout. Spoofed replies are only counted, the
+	// probe keeps waiting for the real one.
+	var outcome Outcome
+	select {
+	case reply := <-probe.reply:
+		outcome = Outcome{Status: StatusAlive, RTT: reply.rtt, Header: reply.header, Size: reply.size}
+		if reply.source != probe.addr {
+			outcome.Source = reply.source
+			outcome.Reply = "reply from other address " + reply.source
+		}
+	case <-ctx.Done():
+		outcome = Outcome{Status: StatusUnreachable}
+	}
+	p.mutex.Lock()
+	outcome.Spoofed = probe.spoofed
+	p.mutex.Unlock()
+	return outcome
+}
+
+// match decodes the payload of a reply and hands the RTT to the probe waiting
+// for it, or counts the reply as unsolicited
+func (p *ICMPProber) match(source string, reply *icmp.Echo, header *ReplyHeader, received time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if reply.ID != p.id {
+		p.unsolicited[unsolicitedKey{source: source, reason: reasonForeignID}]++
+		return
+	}
+
+	// The session token tells replies to an earlier run with the same echo
+	// ID apart, the full sequence number survives the echo sequence wrapping
+	payload, err := parsePayload(reply.Data)
+	if err != nil || payload.Session != p.session || uint32(reply.Seq) != payload.Seq&0xffff {
+		// In keyed mode a reply from a probed address without our payload
+		// is a forgery rather than someone else's reply
+		if probe := p.pendingFrom(source, reply.Seq); probe != nil && p.key != nil {
+			probe.spoofed++
+			return
+		}
+		p.unsolicited[unsolicitedKey{source: source, reason: reasonForeignPayload}]++
+		return
+	}
+
+	probe := p.pending[icmpKey{target: payload.Target, seq: payload.Seq}]
+	if probe == nil {
+		p.unsolicited[unsolicitedKey{source: source, reason: reasonNoProbe}]++
+		return
+	}
+	if p.key != nil && !verifyPayload(reply.Data, p.key) {
+		probe.spoofed++
+		return
+	}
+	if probe.answered {
+		p.unsolicited[unsolicitedKey{source: source, reason: reasonDuplicate}]++
+		return
+	}
+
+	// The channel is buffered, so this never blocks the listener even if
+	// the probe already gave up
+	probe.answered = true
+	probe.reply <- icmpReply{
+		rtt:    received - payload.Sent,
+		header: header,
+		source: source,
+		size:   icmpHeaderSize + len(reply.Data),
+	}
+}
+
+// pendingFrom returns the probe in flight to source with echo sequence seq
+func (p *ICMPProber) pendingFrom(source string, seq int) *pendingProbe {
+	for key, probe := range p.pending {
+		if probe.addr == source && key.seq&0xffff == uint32(seq) {
+			return probe
+		}
+	}
+	return nil
+}
+
+// Unsolicited returns the replies that matched no probe, by source and reason
+func (p *ICMPProber) Unsolicited() []Unsolicited {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	replies := make([]Unsolicited, 0, len(p.unsolicited))
+	for key, count := range p.unsolicited {
+		replies = append(replies, Unsolicited{Source: key.source, Reason: key.reason, Count: count})
+	}
+	sort.Slice(replies, func(i, j int) bool {
+		if replies[i].Source != replies[j].Source {
+			return replies[i].Source < replies[j].Source
+		}
+		return replies[i].Reason < replies[j].Reason
+	})
+	return replies
+}
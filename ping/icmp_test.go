@@ -0,0 +1,305 @@
+package ping
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/icmp"
+)
+
+// newTestProber returns a prober with echo ID 100 that is not bound to a
+// socket, for feeding it replies by hand
+func newTestProber(session uint32, key []byte) *ICMPProber {
+	return &ICMPProber{
+		id:          100,
+		session:     session,
+		key:         key,
+		pending:     make(map[icmpKey]*pendingProbe),
+		unsolicited: make(map[unsolicitedKey]int),
+	}
+}
+
+func TestICMPProberMatch(t *testing.T) {
+	p := newTestProber(0xc0ffee, nil)
+	waiting := &pendingProbe{reply: make(chan icmpReply, 1), addr: "192.0.2.1"}
+	p.pending[icmpKey{target: 3, seq: 65537}] = waiting
+
+	// The probe was sent 10ms after the epoch and answered 25ms after it
+	sent := echoPayload{Session: 0xc0ffee, Target: 3, Seq: 65537, Sent: 10 * time.Millisecond}
+	stale := sent
+	stale.Session = 0xbad
+	wrapped := sent
+	wrapped.Seq = 1
+	replies := []struct {
+		source string
+		id     int
+		data   []byte
+	}{
+		{source: "192.0.2.1", id: 100, data: sent.marshal()},
+		{source: "192.0.2.1", id: 100, data: sent.marshal()},
+		{source: "192.0.2.1", id: 200, data: sent.marshal()},
+		{source: "192.0.2.1", id: 100, data: stale.marshal()},
+		{source: "192.0.2.1", id: 100, data: []byte("ping from someone else")},
+		{source: "192.0.2.1", id: 100, data: wrapped.marshal()},
+		{source: "192.0.2.9", id: 100, data: sent.marshal()},
+		{source: "192.0.2.9", id: 300, data: nil},
+	}
+	header := &ReplyHeader{TTL: 57, ID: 4242, Size: 64}
+	for _, reply := range replies {
+		p.match(reply.source, &icmp.Echo{ID: reply.id, Seq: 1, Data: reply.data}, header, 25*time.Millisecond)
+	}
+
+	select {
+	case reply := <-waiting.reply:
+		if reply.rtt != 15*time.Millisecond || reply.header != header {
+			t.Errorf("probe received RTT %v and header %+v, want 15ms and %+v", reply.rtt, reply.header, header)
+		}
+	default:
+		t.Fatalf("the matching reply was not handed to the probe")
+	}
+
+	expected := []Unsolicited{
+		{Source: "192.0.2.1", Reason: reasonDuplicate, Count: 1},
+		{Source: "192.0.2.1", Reason: reasonForeignID, Count: 1},
+		{Source: "192.0.2.1", Reason: reasonNoProbe, Count: 1},
+		{Source: "192.0.2.1", Reason: reasonForeignPayload, Count: 2},
+		{Source: "192.0.2.9", Reason: reasonDuplicate, Count: 1},
+		{Source: "192.0.2.9", Reason: reasonForeignID, Count: 1},
+	}
+	if got := p.Unsolicited(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unsolicited() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestICMPProberOtherSource(t *testing.T) {
+	p := newTestProber(7, nil)
+	waiting := &pendingProbe{reply: make(chan icmpReply, 1), addr: "192.0.2.1"}
+	p.pending[icmpKey{target: 4, seq: 9}] = waiting
+
+	// A NAT box answers for the address the request was sent to
+	sent := echoPayload{Session: 7, Target: 4, Seq: 9}
+	p.match("198.51.100.7", &icmp.Echo{ID: 100, Seq: 9, Data: sent.marshal()}, nil, time.Millisecond)
+
+	select {
+	case reply := <-waiting.reply:
+		if reply.source != "198.51.100.7" {
+			t.Errorf("reply source = %s, want 198.51.100.7", reply.source)
+		}
+	default:
+		t.Fatalf("the reply from another address was not handed to the probe")
+	}
+	if got := p.Unsolicited(); len(got) != 0 {
+		t.Errorf("Unsolicited() = %+v, want none", got)
+	}
+}
+
+func TestICMPProberKeyedMode(t *testing.T) {
+	key := []byte("shared secret")
+	p := newTestProber(7, key)
+	waiting := &pendingProbe{reply: make(chan icmpReply, 1), addr: "192.0.2.1"}
+	p.pending[icmpKey{target: 0, seq: 2}] = waiting
+
+	sent := echoPayload{Session: 7, Seq: 2}
+	signed := signPayload(sent.marshal(), key)
+	forged := signPayload(sent.marshal(), []byte("guessed secret"))
+	replies := []struct {
+		source string
+		data   []byte
+	}{
+		{source: "192.0.2.1", data: sent.marshal()},
+		{source: "192.0.2.1", data: forged},
+		{source: "192.0.2.1", data: []byte("alive, trust me")},
+		{source: "192.0.2.9", data: []byte("alive, trust me")},
+	}
+	for _, reply := range replies {
+		p.match(reply.source, &icmp.Echo{ID: 100, Seq: 2, Data: reply.data}, nil, time.Millisecond)
+	}
+
+	select {
+	case <-waiting.reply:
+		t.Fatalf("a reply without a valid tag was counted as received")
+	default:
+	}
+	if waiting.spoofed != 3 {
+		t.Errorf("spoofed = %d, want 3", waiting.spoofed)
+	}
+
+	p.match("192.0.2.1", &icmp.Echo{ID: 100, Seq: 2, Data: signed}, nil, time.Millisecond)
+	select {
+	case <-waiting.reply:
+	default:
+		t.Errorf("the reply with a valid tag was not handed to the probe")
+	}
+
+	expected := []Unsolicited{{Source: "192.0.2.9", Reason: reasonForeignPayload, Count: 1}}
+	if got := p.Unsolicited(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unsolicited() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	sent := echoPayload{Session: 7, Target: 1 << 20, Seq: 1 << 17, Sent: 90 * time.Second}
+	data := sent.marshal()
+	if len(data) != payloadSize {
+		t.Fatalf("marshal() returned %d bytes, want %d", len(data), payloadSize)
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected error
+	}{
+		{name: "Valid", data: data},
+		{name: "Foreign", data: []byte("abcdefghijklmnopqrstuvwxyz0123456789"), expected: errPayloadMagic},
+		{name: "Short", data: []byte("GO"), expected: errPayloadMagic},
+		{name: "Newer version", data: append([]byte("GOPG\x02"), data[5:]...), expected: errPayloadVersion},
+		{name: "Truncated", data: data[:payloadSize-1], expected: errPayloadVersion},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parsePayload(test.data)
+			if err != test.expected {
+				t.Fatalf("parsePayload() error = %v, want %v", err, test.expected)
+			}
+			if err == nil && got != sent {
+				t.Errorf("parsePayload() = %+v, want %+v", got, sent)
+			}
+		})
+	}
+}
+
+func TestAllocateID(t *testing.T) {
+	seen := make(map[int]bool)
+	for range 1000 {
+		id, err := allocateID()
+		if err != nil {
+			t.Fatalf("allocateID() error = %v", err)
+		}
+		if seen[id] || id < 0 || id > 0xffff {
+			t.Fatalf("allocateID() = %d, already in use or out of range", id)
+		}
+		seen[id] = true
+	}
+	for id := range seen {
+		releaseID(id)
+	}
+}
+
+func TestSocketDispatch(t *testing.T) {
+	socket := &Socket{sessions: make(map[int]*ICMPProber)}
+	sessions := make([]*ICMPProber, 2)
+	for i := range sessions {
+		sessions[i] = newTestProber(uint32(i), nil)
+		id, err := socket.register(sessions[i])
+		if err != nil {
+			t.Fatalf("register() error = %v", err)
+		}
+		sessions[i].id = id
+		defer socket.unregister(id)
+	}
+
+	// Both sessions probe the same address with the same sequence
+	waiting := make([]*pendingProbe, 2)
+	for i, session := range sessions {
+		waiting[i] = &pendingProbe{reply: make(chan icmpReply, 1), addr: "192.0.2.1"}
+		session.pending[icmpKey{seq: 1}] = waiting[i]
+	}
+
+	now := monotonicNow()
+	data := echoPayload{Session: sessions[1].session, Seq: 1}.marshal()
+	socket.dispatch("192.0.2.1", &icmp.Echo{ID: sessions[1].id, Seq: 1, Data: data}, nil, now)
+	select {
+	case <-waiting[1].reply:
+	default:
+		t.Errorf("the reply was not handed to its own session")
+	}
+	select {
+	case <-waiting[0].reply:
+		t.Errorf("the reply was handed to the other session")
+	default:
+	}
+
+	// A reply for another process is counted by every session
+	foreign := (sessions[0].id + 1) & 0xffff
+	for foreign == sessions[1].id {
+		foreign = (foreign + 1) & 0xffff
+	}
+	socket.dispatch("192.0.2.1", &icmp.Echo{ID: foreign, Seq: 1}, nil, now)
+	for i, session := range sessions {
+		expected := []Unsolicited{{Source: "192.0.2.1", Reason: reasonForeignID, Count: 1}}
+		if got := session.Unsolicited(); !reflect.DeepEqual(got, expected) {
+			t.Errorf("session %d: Unsolicited() = %+v, want %+v", i, got, expected)
+		}
+	}
+}
+
+func TestPingersShareSocket(t *testing.T) {
+	socket, err := OpenSocket()
+	if err != nil {
+		t.Skipf("cannot open a raw ICMP socket: %v", err)
+	}
+	defer socket.Close()
+
+	config := Config{Count: 2, Timeout: time.Second, Quiet: true, Socket: socket}
+	keyed := config
+	keyed.Key = []byte("shared secret")
+	pingers := []*Pinger{
+		NewPinger(mustParseSpecs(t, "127.0.0.1"), config),
+		NewPinger(mustParseSpecs(t, "127.0.0.1"), keyed),
+	}
+	errs := make(chan error, len(pingers))
+	for _, pinger := range pingers {
+		go func() { errs <- pinger.Run() }()
+	}
+	for range pingers {
+		if err := <-errs; err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+
+	for i, pinger := range pingers {
+		result := pinger.Results()[0]
+		if len(result.Headers) != result.Received || (len(result.Headers) > 0 && result.Headers[0].TTL == 0) {
+			t.Errorf("pinger %d recorded headers %+v, want a TTL for every reply", i, result.Headers)
+		}
+		if result.Received != 2 || result.Spoofed != 0 {
+			t.Errorf("pinger %d received %d/%d replies and %d spoofed, want 2/2 and none", i, result.Received, result.Sent, result.Spoofed)
+		}
+	}
+}
+
+func TestICMPProbeHostPort(t *testing.T) {
+	socket, err := OpenSocket()
+	if err != nil {
+		t.Skipf("cannot open a raw ICMP socket: %v", err)
+	}
+	defer socket.Close()
+
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(hosts, []byte("127.0.0.1 loopback.test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewResolver()
+	if err := resolver.LoadHosts(hosts); err != nil {
+		t.Fatalf("LoadHosts() error = %v", err)
+	}
+
+	// The port of a plain or icmp:// target is not part of the name
+	config := Config{Count: 1, Timeout: time.Second, Quiet: true, Socket: socket, Resolver: resolver}
+	pinger := NewPinger(mustParseSpecs(t, "127.0.0.1:8080", "loopback.test:8080", "icmp://loopback.test:7"), config)
+	if err := pinger.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for _, result := range pinger.Results() {
+		if result.Unresolved || result.Received != 1 {
+			t.Errorf("%s: received %d/%d replies, unresolved %v, want 1/1", result.Name(), result.Received, result.Sent, result.Unresolved)
+		}
+	}
+	if got := len(pinger.Results()); got != 3 {
+		t.Errorf("got %d results, want 3", got)
+	}
+}
@@ -0,0 +1,97 @@
+package ping
+
+import "fmt"
+
+const (
+	// ipidMinReplies is the number of replies needed before the IP IDs of a
+	// target are analyzed
+	ipidMinReplies = 4
+	// ipidMaxStep is the largest increase between two replies that is still
+	// taken as the same counter, busy hosts send other traffic in between
+	ipidMaxStep = 4096
+)
+
+// IP ID patterns
+const (
+	IPIDUnknown     = "too few replies"
+	IPIDConstant    = "constant"
+	IPIDIncremental = "incremental"
+	IPIDRandom      = "random"
+)
+
+// IPIDAnalysis describes the IP IDs of the replies from one target
+type IPIDAnalysis struct {
+	Pattern string
+	// Counters is the number of independent incrementing counters seen,
+	// each one usually being a separate host behind the address
+	Counters int
+}
+
+// MultipleHosts reports whether the replies came from more than one host
+func (a IPIDAnalysis) MultipleHosts() bool {
+	return a.Pattern == IPIDIncremental && a.Counters > 1
+}
+
+// AnalyzeIPID splits the IP IDs of replies, in the order they arrived, into
+// incrementing counters. A host keeps a counter of its own, so replies from a
+// load-balanced or anycast address show one counter per backend. Each ID
+// continues the counter it is the smallest step ahead of, or starts a new
+// one. Only counters with at least two replies count, a lone ID is an
+// outlier rather than another host, and mostly lone IDs mean random IDs.
+func AnalyzeIPID(headers []ReplyHeader) IPIDAnalysis {
+	if len(headers) < ipidMinReplies {
+		return IPIDAnalysis{Pattern: IPIDUnknown}
+	}
+
+	type counter struct {
+		last    int
+		replies int
+	}
+	constant := true
+	var counters []counter
+	for _, header := range headers {
+		if header.ID != headers[0].ID {
+			constant = false
+		}
+
+		best := -1
+		bestStep := ipidMaxStep + 1
+		for i, c := range counters {
+			step := (header.ID - c.last + 0x10000) & 0xffff
+			if step > 0 && step < bestStep {
+				best, bestStep = i, step
+			}
+		}
+		if best < 0 {
+			counters = append(counters, counter{last: header.ID, replies: 1})
+		} else {
+			counters[best].last = header.ID
+			counters[best].replies++
+		}
+	}
+
+	backed, lone := 0, 0
+	for _, c := range counters {
+		if c.replies >= 2 {
+			backed++
+		} else {
+			lone++
+		}
+	}
+
+	switch {
+	case constant:
+		return IPIDAnalysis{Pattern: IPIDConstant}
+	case lone*2 > len(headers) || backed == 0:
+		return IPIDAnalysis{Pattern: IPIDRandom}
+	}
+	return IPIDAnalysis{Pattern: IPIDIncremental, Counters: backed}
+}
+
+// String describes the analysis for the summary
+func (a IPIDAnalysis) String() string {
+	if a.MultipleHosts() {
+		return fmt.Sprintf("ip-id shows %d independent counters, likely %d hosts behind this address", a.Counters, a.Counters)
+	}
+	return "ip-id " + a.Pattern
+}
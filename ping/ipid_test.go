@@ -0,0 +1,69 @@
+package ping
+
+import "testing"
+
+func TestAnalyzeIPID(t *testing.T) {
+	tests := []struct {
+		name     string
+		ids      []int
+		expected IPIDAnalysis
+	}{
+		{
+			name:     "Too few replies",
+			ids:      []int{1, 2, 3},
+			expected: IPIDAnalysis{Pattern: IPIDUnknown},
+		},
+		{
+			name:     "Constant",
+			ids:      []int{0, 0, 0, 0, 0},
+			expected: IPIDAnalysis{Pattern: IPIDConstant},
+		},
+		{
+			name:     "Single host",
+			ids:      []int{100, 101, 150, 152, 160},
+			expected: IPIDAnalysis{Pattern: IPIDIncremental, Counters: 1},
+		},
+		{
+			name:     "Outlier",
+			ids:      []int{100, 101, 102, 103, 104, 50000},
+			expected: IPIDAnalysis{Pattern: IPIDIncremental, Counters: 1},
+		},
+		{
+			name:     "Repeated ID",
+			ids:      []int{100, 101, 101, 102, 103},
+			expected: IPIDAnalysis{Pattern: IPIDIncremental, Counters: 1},
+		},
+		{
+			name:     "Counter wraps",
+			ids:      []int{65530, 65534, 2, 9},
+			expected: IPIDAnalysis{Pattern: IPIDIncremental, Counters: 1},
+		},
+		{
+			name:     "Two backends",
+			ids:      []int{100, 30000, 103, 30010, 107, 30011},
+			expected: IPIDAnalysis{Pattern: IPIDIncremental, Counters: 2},
+		},
+		{
+			name:     "Three backends",
+			ids:      []int{100, 20000, 40000, 102, 20001, 40005, 110, 20009, 40006},
+			expected: IPIDAnalysis{Pattern: IPIDIncremental, Counters: 3},
+		},
+		{
+			name:     "Random",
+			ids:      []int{51234, 7, 40211, 18422, 9031, 62000},
+			expected: IPIDAnalysis{Pattern: IPIDRandom},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var headers []ReplyHeader
+			for _, id := range test.ids {
+				headers = append(headers, ReplyHeader{ID: id})
+			}
+			if got := AnalyzeIPID(headers); got != test.expected {
+				t.Errorf("AnalyzeIPID() = %+v, want %+v", got, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package ping
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite holds one testcase per target
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string          `xml:"name,attr"`
+	ClassName  string          `xml:"classname,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Failure    *junitFailure   `xml:"failure,omitempty"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the kept results as a JUnit XML report with one testcase
+// per target. A target fails if it never answered or broke an assertion, and
+// its packet and RTT statistics are listed as properties.
+func (p *Pinger) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:      "goping",
+		Timestamp: time.Now().Format("2006-01-02T15:04:05"),
+	}
+	for _, result := range p.Results() {
+		testCase := junitCase(result, p.failuresOf(result))
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// failuresOf returns the failed assertions of result, including those of its group
+func (p *Pinger) failuresOf(result *Result) []AssertionFailure {
+	var failures []AssertionFailure
+	for _, failure := range p.failures {
+		if slices.Contains(failure.results, result) {
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+// junitCase describes one target as a testcase
+func junitCase(result *Result, failures []AssertionFailure) junitTestCase {
+	testCase := junitTestCase{Name: result.Name(), ClassName: "goping"}
+
+	loss := 100.0
+	if result.Sent > 0 {
+		loss = float64(result.Sent-result.Received) / float64(result.Sent) * 100
+	}
+	testCase.Properties = []junitProperty{
+		{Name: "sent", Value: fmt.Sprint(result.Sent)},
+		{Name: "received", Value: fmt.Sprint(result.Received)},
+		{Name: "loss_pct", Value: fmt.Sprintf("%0.1f", loss)},
+	}
+	out := fmt.Sprintf("%s : %d/%d packets, %0.1f%% loss", result.Name(), result.Received, result.Sent, loss)
+	if result.Received > 0 {
+		s := sampleOf(result)
+		avg, p95, jitter := s.rtt(StatAvg), s.rtt(StatP95), s.rtt(StatJitter)
+		for _, rtt := range []struct {
+			name  string
+			value time.Duration
+		}{
+			{"rtt_min_ms", result.MinRTT},
+			{"rtt_avg_ms", avg},
+			{"rtt_max_ms", result.MaxRTT},
+			{"rtt_p95_ms", p95},
+			{"jitter_ms", jitter},
+		} {
+			testCase.Properties = append(testCase.Properties, junitProperty{
+				Name:  rtt.name,
+				Value: fmt.Sprintf("%.3f", float64(rtt.value)/float64(time.Millisecond)),
+			})
+		}
+		out += fmt.Sprintf(", min/avg/max/p95/jitter = %v/%v/%v/%v/%v", result.MinRTT, avg, result.MaxRTT, p95, jitter)
+	}
+	testCase.SystemOut = out
+
+	switch {
+	case len(failures) > 0:
+		var lines []string
+		for _, failure := range failures {
+			lines = append(lines, failure.String())
+		}
+		testCase.Failure = &junitFailure{
+			Message: fmt.Sprintf("%s, %s %s", failures[0].Assertion, failures[0].Assertion.Stat, failures[0].Value),
+			Type:    "assertion",
+			Text:    strings.Join(lines, "\n"),
+		}
+	case result.Unresolved:
+		testCase.Failure = &junitFailure{Message: "could not be resolved", Type: "unresolved"}
+	case result.Received == 0:
+		testCase.Failure = &junitFailure{Message: fmt.Sprintf("no reply to %d probes", result.Sent), Type: "unreachable"}
+	}
+	return testCase
+}
@@ -0,0 +1,74 @@
+package ping
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	p := NewPinger(nil, Config{})
+	p.results = []*Result{
+		{Target: "10.0.0.1", Sent: 4, Received: 4, MinRTT: time.Millisecond, MaxRTT: 3 * time.Millisecond,
+			RTTs: []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 2 * time.Millisecond}},
+		{Target: "10.0.0.2", Sent: 4, index: 1},
+		{Target: "10.0.0.3", Sent: 2, Received: 2, RTTs: []time.Duration{40 * time.Millisecond, 40 * time.Millisecond}, index: 2},
+		{Target: "missing.example", Unresolved: true, index: 3},
+		// The same host listed twice only fails where the assertion failed
+		{Target: "10.0.0.3", Sent: 2, Received: 2, RTTs: []time.Duration{time.Millisecond, time.Millisecond}, index: 4},
+	}
+	p.failures = []AssertionFailure{
+		{Target: "10.0.0.3", Assertion: Assertion{Stat: StatAvg, MaxRTT: 20 * time.Millisecond}, Value: "40ms", results: p.results[2:3]},
+	}
+
+	var out bytes.Buffer
+	if err := p.WriteJUnit(&out); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), xml.Header) {
+		t.Errorf("report does not start with an XML header:\n%s", out.String())
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("report is not valid XML: %v\n%s", err, out.String())
+	}
+	suite := report.Suites[0]
+	if suite.Tests != 5 || suite.Failures != 3 {
+		t.Errorf("suite has %d tests and %d failures, want 5 and 3", suite.Tests, suite.Failures)
+	}
+
+	tests := []struct {
+		name        string
+		failureType string
+		property    junitProperty
+	}{
+		{name: "10.0.0.1", property: junitProperty{Name: "rtt_avg_ms", Value: "2.000"}},
+		{name: "10.0.0.2", failureType: "unreachable", property: junitProperty{Name: "loss_pct", Value: "100.0"}},
+		{name: "10.0.0.3", failureType: "assertion", property: junitProperty{Name: "jitter_ms", Value: "0.000"}},
+		{name: "missing.example", failureType: "unresolved", property: junitProperty{Name: "sent", Value: "0"}},
+		{name: "10.0.0.3", property: junitProperty{Name: "rtt_avg_ms", Value: "1.000"}},
+	}
+	for i, test := range tests {
+		testCase := suite.Cases[i]
+		if testCase.Name != test.name {
+			t.Errorf("testcase %d is %q, want %q", i, testCase.Name, test.name)
+		}
+		failureType := ""
+		if testCase.Failure != nil {
+			failureType = testCase.Failure.Type
+		}
+		if failureType != test.failureType {
+			t.Errorf("%s: failure type = %q, want %q", test.name, failureType, test.failureType)
+		}
+		found := false
+		for _, property := range testCase.Properties {
+			found = found || property == test.property
+		}
+		if !found {
+			t.Errorf("%s: properties %+v do not include %+v", test.name, testCase.Properties, test.property)
+		}
+	}
+}
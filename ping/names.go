@@ -0,0 +1,68 @@
+package ping
+
+import (
+	"context"
+	"net"
+
+	"github.com/windows-fping/goping/target"
+)
+
+// nameResult works out how result is shown with ShowNames and ShowAddresses.
+// The lookups run in the background so a slow resolver never holds back the
+// output. Lines printed before they are done show the target as it is, later
+// lines and the summary show the name.
+func (p *Pinger) nameResult(spec target.Spec, result *Result) {
+	if !p.config.ShowNames && !p.config.ShowAddresses {
+		return
+	}
+
+	p.naming.Add(1)
+	go func() {
+		defer p.naming.Done()
+		display := p.displayName(spec)
+
+		p.mutex.Lock()
+		result.display = display
+		p.mutex.Unlock()
+	}()
+}
+
+// displayName returns how spec is shown, or "" to show its target as it is.
+// With ShowNames addresses are replaced by the name from their PTR record,
+// with ShowAddresses names are replaced by their address, and with both the
+// address follows the name. Failed lookups leave the target unchanged.
+func (p *Pinger) displayName(spec target.Spec) string {
+	if spec.Host == "" {
+		return ""
+	}
+
+	// Both lookups are bounded by the resolver's timeout
+	ctx := context.Background()
+	resolver := p.config.Resolver
+
+	if ip := net.ParseIP(spec.Host); ip != nil {
+		if !p.config.ShowNames {
+			return ""
+		}
+		name, err := resolver.Reverse(ctx, ip)
+		if err != nil {
+			return ""
+		}
+		if p.config.ShowAddresses {
+			return withHost(spec, name).Target + " (" + spec.Host + ")"
+		}
+		return withHost(spec, name).Target
+	}
+
+	if !p.config.ShowAddresses {
+		return ""
+	}
+	ips, err := resolver.Lookup(ctx, spec.Host)
+	if err != nil {
+		return ""
+	}
+	if p.config.ShowNames || nameSchemes[p.schemeOf(spec)] {
+		return spec.Target + " (" + ips[0].String() + ")"
+	}
+	return withHost(spec, ips[0].String()).Target
+}
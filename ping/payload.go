@@ -0,0 +1,118 @@
+package ping
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// The echo payload starts with a versioned header, so that a reply carries
+// everything needed to match it and compute its RTT:
+//
+//	magic    4 bytes  "GOPG"
+//	version  1 byte   payloadVersion
+//	reserved 3 bytes  zero
+//	session  4 bytes  random token of the sending session
+//	target   4 bytes  index of the target in the run
+//	seq      4 bytes  full sequence number, which does not wrap at 65536
+//	sent     8 bytes  monotonic send time in nanoseconds
+//
+// In keyed mode the header is followed by a tag, the first 16 bytes of the
+// HMAC-SHA256 of session, target, seq and sent. The rest of the echo data is
+// zero padding up to echoDataSize.
+//
+// The tag travels in the request and comes back unchanged in the reply, so it
+// only rejects replies forged blind. Anyone who can read the request can copy
+// its tag into a forged reply, the tag does not prove the target answered.
+const (
+	payloadMagic   = "GOPG"
+	payloadVersion = 1
+	payloadSize    = 28
+	tagSize        = 16
+	// echoDataSize is the default echo data size of ping and fping, so
+	// replies are as large as theirs
+	echoDataSize = 56
+)
+
+var (
+	errPayloadMagic   = errors.New("payload not sent by goping")
+	errPayloadVersion = errors.New("unsupported payload version")
+)
+
+// epoch anchors monotonic timestamps, time.Since uses the monotonic clock
+var epoch = time.Now()
+
+// monotonicNow returns the time elapsed since the process started
+func monotonicNow() time.Duration {
+	return time.Since(epoch)
+}
+
+// echoPayload is the header carried by every echo request
+type echoPayload struct {
+	Session uint32
+	Target  uint32
+	Seq     uint32
+	Sent    time.Duration
+}
+
+// marshal encodes the header
+func (e echoPayload) marshal() []byte {
+	b := make([]byte, payloadSize)
+	copy(b, payloadMagic)
+	b[4] = payloadVersion
+	binary.BigEndian.PutUint32(b[8:], e.Session)
+	binary.BigEndian.PutUint32(b[12:], e.Target)
+	binary.BigEndian.PutUint32(b[16:], e.Seq)
+	binary.BigEndian.PutUint64(b[20:], uint64(e.Sent))
+	return b
+}
+
+// parsePayload decodes the header at the start of an echo payload
+func parsePayload(b []byte) (echoPayload, error) {
+	if len(b) < 5 || string(b[:4]) != payloadMagic {
+		return echoPayload{}, errPayloadMagic
+	}
+	if b[4] != payloadVersion || len(b) < payloadSize {
+		return echoPayload{}, errPayloadVersion
+	}
+	return echoPayload{
+		Session: binary.BigEndian.Uint32(b[8:]),
+		Target:  binary.BigEndian.Uint32(b[12:]),
+		Seq:     binary.BigEndian.Uint32(b[16:]),
+		Sent:    time.Duration(binary.BigEndian.Uint64(b[20:])),
+	}, nil
+}
+
+// signPayload appends the tag of payload to it, or returns it as it is
+// without a key
+func signPayload(payload []byte, key []byte) []byte {
+	if key == nil {
+		return payload
+	}
+	return append(payload, payloadTag(payload, key)...)
+}
+
+// padPayload pads payload with zeros to echoDataSize
+func padPayload(payload []byte) []byte {
+	if len(payload) >= echoDataSize {
+		return payload
+	}
+	return append(payload, make([]byte, echoDataSize-len(payload))...)
+}
+
+// verifyPayload reports whether payload carries a valid tag
+func verifyPayload(payload []byte, key []byte) bool {
+	if len(payload) < payloadSize+tagSize {
+		return false
+	}
+	return hmac.Equal(payload[payloadSize:payloadSize+tagSize], payloadTag(payload, key))
+}
+
+// payloadTag computes the tag of the authenticated header fields
+func payloadTag(payload []byte, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload[8:payloadSize])
+	return mac.Sum(nil)[:tagSize]
+}
@@ -1,15 +1,17 @@
 package ping
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"math"
-	"net"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
-	"golang.org/x/net/icmp"
-	"golang.org/x/net/ipv4"
+	"github.com/windows-fping/goping/target"
 )
 
 // Config holds the configuration for the Pinger
@@ -22,11 +24,59 @@ type Config struct {
 	UnreachableOnly bool
 	Quiet           bool
 	ShowStats       bool
+	// DefaultScheme is the probe type for targets without a scheme, "icmp" if empty
+	DefaultScheme string
+	UDPPort       int
+	UDPPayload    []byte
+	HTTPMethod    string
+	CertWarnDays  int
+	// KeepResults keeps the result of every target for Results. Otherwise
+	// only results needed for the summary or carrying a warning are kept, so
+	// that huge sweeps run in constant memory.
+	KeepResults bool
+	// AllAddresses probes every IPv4 address of a hostname, like fping -m,
+	// instead of the first one
+	AllAddresses bool
+	// ShowNames shows addresses by the name from their PTR record, like fping -n
+	ShowNames bool
+	// ShowAddresses shows names by their address, like fping -A
+	ShowAddresses bool
+	// Key turns on keyed mode: every echo request carries an HMAC tag made
+	// with Key, and replies without a valid tag count as spoofed
+	Key []byte
+	// Socket is a raw ICMP socket shared with other Pingers, each ICMP
+	// prober opens its own if nil
+	Socket *Socket
+	// Resolver resolves the hostnames of all probes, a caching system
+	// resolver if nil
+	Resolver *Resolver
+	// Exclude lists addresses that are never probed, also when a hostname
+	// resolves to them. Such targets are skipped and not counted.
+	Exclude *target.ExcludeList
+	// Set is the set the targets come from, if any. Addresses a hostname
+	// resolves to with AllAddresses are skipped if the set has them already,
+	// and fping's count mode pads hosts to the longest target of the set.
+	Set *target.Set
+	// CountReport reports every probe and lists the RTTs of every target
+	// on stderr at the end, in the format of fping -C
+	CountReport bool
+	// Elapsed reports each target once as alive with its RTT or as
+	// unreachable, in the format of fping -e
+	Elapsed bool
+	// Timestamps puts the Unix time in front of every probe line, like fping -D
+	Timestamps bool
+	// Assertions are limits every target has to stay within
+	Assertions []Assertion
+	// AssertGroups checks Assertions against the pooled probes of every
+	// hostname expanded with AllAddresses instead of each of its addresses
+	AssertGroups bool
 }
 
 // Result represents the result of a ping
 type Result struct {
-	Target    string
+	Target string
+	// Label is the label given in the target spec, if any
+	Label     string
 	Sent      int
 	Received  int
 	RTTs      []time.Duration
@@ -34,293 +84,409 @@ type Result struct {
 	MaxRTT    time.Duration
 	AvgRTT    time.Duration
 	StdDevRTT time.Duration
+	// HTTPTimings holds the request phases of every answered HTTP probe
+	HTTPTimings []HTTPTiming
+	// Rcodes counts the response codes of answered DNS probes
+	Rcodes map[string]int
+	// TLS describes the session of the last answered TLS probe
+	TLS *TLSInfo
+	// Warning is the last warning raised for the target, if any
+	Warning string
+	// Unresolved is set if the target's hostname could not be resolved
+	Unresolved bool
+	// Group is the hostname the target was resolved from with AllAddresses
+	Group string
+	// Spoofed counts replies that were rejected in keyed mode because
+	// their tag was missing or invalid
+	Spoofed int
+	// History holds the RTT of every probe sent, in order, and a negative
+	// value for each probe that got no reply
+	History []time.Duration
+	// Headers holds the IP header fields of every answered ICMP probe
+	Headers []ReplyHeader
+	// OtherSources counts the replies that came from another address than
+	// the probed one, by that address
+	OtherSources map[string]int
+
+	// index is the position of the target in the order it was scheduled
+	index int
+	// display replaces Target in the output if set
+	display string
+	// excluded is set if the target resolved to an excluded address, it is
+	// skipped and not counted
+	excluded bool
+}
+
+// Name returns the target followed by its label, if it has one
+func (r *Result) Name() string {
+	if r.Label == "" {
+		return r.host()
+	}
+	return r.host() + " [" + r.Label + "]"
+}
+
+// host returns the target as shown in the output, followed by the hostname it
+// was resolved from, if any
+func (r *Result) host() string {
+	host := r.Target
+	if r.display != "" {
+		host = r.display
+	}
+	if r.Group != "" {
+		host += " (" + r.Group + ")"
+	}
+	return host
+}
+
+// Totals counts the targets of a run by how they ended
+type Totals struct {
+	Targets    int
+	Alive      int
+	Unresolved int
+	// Warnings counts the targets that raised a warning
+	Warnings int
+	// Failed counts the assertions that failed
+	Failed int
 }
 
-// Pinger is responsible for sending pings and receiving responses
+// Pinger schedules probes to all targets and collects their results. The
+// probes themselves are sent by the Prober registered for each target's scheme.
+// Targets are consumed lazily, so probing starts before a large sweep has
+// been generated in full.
 type Pinger struct {
-	targets []string
+	targets target.Iterator
 	config  Config
-	results map[string]*Result
-	conn    *icmp.PacketConn
-	mutex   sync.Mutex
-	wg      sync.WaitGroup
-	done    chan struct{}
+	results []*Result
+	// totals counts every finished target, including those not kept in results
+	totals  Totals
+	probers map[string]Prober
+	// unsolicited holds the replies that matched no probe of the run
+	unsolicited []Unsolicited
+	// failures holds the assertions that failed in the run
+	failures []AssertionFailure
+	// expanded holds the targets hostnames were expanded to with AllAddresses
+	expanded map[string]bool
+	// width is the length of the longest target of the set, which fping's
+	// count mode pads hosts to. Names and groups that are only known once
+	// printed widen it as they come.
+	width int
+	mutex sync.Mutex
+	wg    sync.WaitGroup
+	// naming waits for the lookups of nameResult
+	naming sync.WaitGroup
 }
 
 // NewPinger creates a new Pinger
-func NewPinger(targets []string, config Config) *Pinger {
-	return &Pinger{
-		targets: targets,
-		config:  config,
-		results: make(map[string]*Result),
-		done:    make(chan struct{}),
+func NewPinger(targets target.Iterator, config Config) *Pinger {
+	if config.DefaultScheme == "" {
+		config.DefaultScheme = DefaultScheme
+	}
+	if config.Resolver == nil {
+		config.Resolver = NewResolver()
+	}
+	p := &Pinger{
+		targets:  targets,
+		config:   config,
+		probers:  make(map[string]Prober),
+		expanded: make(map[string]bool),
 	}
+	if config.Set != nil {
+		p.width = config.Set.Width()
+	}
+	return p
 }
 
 // Run starts the pinging process
 func (p *Pinger) Run() error {
+	defer func() {
+		for _, prober := range p.probers {
+			prober.Close()
+		}
+	}()
+
+	// Send probes, preparing one prober per scheme the first time it is used
 	var err error
-	
-	// Prepare results map
-	for _, target := range p.targets {
-		p.results[target] = &Result{
-			Target: target,
-			RTTs:   make([]time.Duration, 0, p.config.Count),
-			MinRTT: time.Duration(math.MaxInt64),
-			MaxRTT: 0,
+	index := 0
+scheduling:
+	for original := range p.targets {
+		specs := p.expand(original)
+		for _, spec := range specs {
+			var prober Prober
+			prober, err = p.proberFor(spec)
+			if err != nil {
+				break scheduling
+			}
+
+			result := &Result{
+				Target: spec.Target,
+				Label:  spec.Label,
+				RTTs:   make([]time.Duration, 0, p.countOf(spec)),
+				MinRTT: time.Duration(math.MaxInt64),
+				MaxRTT: 0,
+				index:  index,
+			}
+			if len(specs) > 1 || spec.Host != original.Host {
+				result.Group = original.Host
+			}
+			index++
+
+			p.wg.Add(1)
+			go func(spec target.Spec, prober Prober, result *Result) {
+				defer p.wg.Done()
+				p.probeTarget(spec, prober, result)
+				p.keepResult(result)
+			}(spec, prober, result)
+
+			// Wait between probes to different targets
+			time.Sleep(p.config.Period)
 		}
 	}
-	
-	// Open ICMP connection
-	p.conn, err = icmp.ListenPacket("ip4:icmp", "0.0.0.0")
-	if err != nil {
-		return fmt.Errorf("error opening connection: %w", err)
-	}
-	
-	// Using a WaitGroup to track when the listener goroutine exits
-	var listenerWg sync.WaitGroup
-	listenerWg.Add(1)
-	
-	// Start the listener goroutine
-	go func() {
-		defer listenerWg.Done()
-		p.listener()
-	}()
-	
-	// Send pings
-	err = p.sendPings()
+
+	// Wait for all probes to complete, and for the names of their targets
+	p.wg.Wait()
+	p.naming.Wait()
 	if err != nil {
-		close(p.done)
-		listenerWg.Wait() // Wait for listener to exit
-		p.conn.Close()    // Close connection after listener exits
-		return fmt.Errorf("error sending pings: %w", err)
+		return err
+	}
+
+	// Collect the replies that matched no probe before the probers close
+	for _, prober := range p.probers {
+		if reporter, ok := prober.(UnsolicitedReporter); ok {
+			p.unsolicited = append(p.unsolicited, reporter.Unsolicited()...)
+		}
+	}
+
+	// Like fping, count mode reports every target on stderr instead of a summary
+	if p.countMode() {
+		p.printCountReport(os.Stderr)
 	}
-	
-	// Wait for all pings to complete
-	p.wg.Wait()
-	close(p.done)
-	
-	// Wait for listener to exit before closing the connection
-	listenerWg.Wait()
-	p.conn.Close()
-	
 	// Print summary if requested or in quiet mode
-	if p.config.ShowStats || p.config.Quiet {
+	if p.config.ShowStats || (p.config.Quiet && !p.countMode()) {
 		p.printSummary()
 	}
-	
+
+	p.failures = p.checkAssertions()
+	p.totals.Failed = len(p.failures)
+	p.printFailures()
+
 	return nil
 }
 
-// sendPings sends pings to all targets
-func (p *Pinger) sendPings() error {
-	// Create message
-	msg := icmp.Message{
-		Type: ipv4.ICMPTypeEcho,
-		Code: 0,
-		Body: &icmp.Echo{
-			ID:   os.Getpid() & 0xffff,
-			Seq:  1,
-			Data: []byte("goping"),
-		},
-	}
-	
-	// We're not using this variable, so no need to check for errors here
-	_, _ = msg.Marshal(nil)
-	
-	// Send pings to each target
-	for i := 0; i < p.config.Count; i++ {
-		for _, target := range p.targets {
-			p.wg.Add(1)
-			go func(target string, seq int) {
-				defer p.wg.Done()
-				
-				// Resolve hostname to IP
-				ipAddr, err := net.ResolveIPAddr("ip4", target)
-				if err != nil {
-					if !p.config.Quiet {
-						fmt.Printf("%s : Cannot resolve: %v\n", target, err)
-					}
-					return
-				}
-				
-				// Update sequence number
-				echo := msg.Body.(*icmp.Echo)
-				echo.Seq = seq
-				updatedMsg := icmp.Message{
-					Type: msg.Type,
-					Code: msg.Code,
-					Body: echo,
-				}
-				
-				msgBytes, err := updatedMsg.Marshal(nil)
-				if err != nil {
-					fmt.Printf("Error marshaling message for %s: %v\n", target, err)
-					return
-				}
-				
-				// Send the ping
-				p.mutex.Lock()
-				p.results[target].Sent++
-				p.mutex.Unlock()
-				
-				_, err = p.conn.WriteTo(msgBytes, ipAddr)
-				if err != nil {
-					fmt.Printf("Error sending to %s: %v\n", target, err)
-					return
-				}
-				
-				// Set up timeout
-				timer := time.NewTimer(p.config.Timeout)
-				defer timer.Stop()
-				
-				// Wait for response or timeout
-				select {
-				case <-timer.C:
-					if !p.config.Quiet && !p.config.AliveOnly {
-						fmt.Printf("%s : timeout\n", target)
-					}
-				case <-p.done:
-					return
-				}
-				
-				// Add response time if received (handled in listener)
-				p.mutex.Lock()
-				if len(p.results[target].RTTs) < p.results[target].Sent {
-					// Not received
-				}
-				p.mutex.Unlock()
-				
-				// Wait before sending next ping
-				if i < p.config.Count-1 {
-					time.Sleep(p.config.Interval)
-				}
-			}(target, i+1)
-			
-			// Wait between pings to different targets
-			time.Sleep(p.config.Period)
+// proberFor returns the prober for spec's scheme, creating it on first use
+func (p *Pinger) proberFor(spec target.Spec) (Prober, error) {
+	scheme := p.schemeOf(spec)
+	if prober := p.probers[scheme]; prober != nil {
+		return prober, nil
+	}
+	prober, err := newProber(scheme)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", spec.Target, err)
+	}
+	if err := prober.Prepare(p.config); err != nil {
+		return nil, err
+	}
+	p.probers[scheme] = prober
+	return prober, nil
+}
+
+// keepResult counts a finished result and stores it if it is needed later
+func (p *Pinger) keepResult(result *Result) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if result.excluded {
+		return
+	}
+
+	p.totals.Targets++
+	if result.Received > 0 {
+		p.totals.Alive++
+	}
+	if result.Unresolved {
+		p.totals.Unresolved++
+	}
+	if result.Warning != "" {
+		p.totals.Warnings++
+	}
+
+	if !p.config.KeepResults && !p.config.ShowStats && !p.config.Quiet && !p.countMode() &&
+		len(p.config.Assertions) == 0 && result.Warning == "" {
+		return
+	}
+	p.results = append(p.results, result)
+}
+
+// Totals returns how many targets of the run were alive, unresolved or
+// raised a warning. Unlike Results it covers every target.
+func (p *Pinger) Totals() Totals {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.totals
+}
+
+// Results returns the kept results in the order the targets were given
+func (p *Pinger) Results() []*Result {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	results := slices.Clone(p.results)
+	slices.SortFunc(results, func(a, b *Result) int { return a.index - b.index })
+	return results
+}
+
+// NeedsRawSocket reports whether any of the parsed specs is probed with ICMP
+// echo, which requires administrator privileges. It looks at the specs rather
+// than the targets, so networks are not expanded before the first probe.
+func (p *Pinger) NeedsRawSocket(specs []target.Spec) bool {
+	for _, spec := range specs {
+		if p.schemeOf(spec) == "icmp" {
+			return true
 		}
 	}
-	
-	return nil
+	return false
+}
+
+// schemeOf returns the probe type used for spec
+func (p *Pinger) schemeOf(spec target.Spec) string {
+	if spec.Scheme != "" {
+		return spec.Scheme
+	}
+	return p.config.DefaultScheme
 }
 
-// listener listens for ICMP responses and processes them
-func (p *Pinger) listener() {
-	buffer := make([]byte, 1500)
-	
-	for {
-		select {
-		case <-p.done:
+// countOf returns the number of probes for spec, which may override -c
+func (p *Pinger) countOf(spec target.Spec) int {
+	if spec.Count > 0 {
+		return spec.Count
+	}
+	return p.config.Count
+}
+
+// probeTarget sends all probes to a single target, one interval apart
+func (p *Pinger) probeTarget(spec target.Spec, prober Prober, result *Result) {
+	timeout := p.config.Timeout
+	if spec.Timeout > 0 {
+		timeout = spec.Timeout
+	}
+
+	p.nameResult(spec, result)
+
+	count := p.countOf(spec)
+	for seq := 1; seq <= count; seq++ {
+		ctx, cancel := context.WithTimeout(withTargetIndex(context.Background(), result.index), timeout)
+		outcome := prober.Probe(ctx, spec.Target, seq)
+		cancel()
+		p.recordOutcome(result, seq, outcome)
+		if result.excluded {
 			return
-		default:
-			// Set read deadline
-			err := p.conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
-			if err != nil {
-				fmt.Printf("Error setting read deadline: %v\n", err)
-				continue
-			}
-			
-			// Read packet
-			n, addr, err := p.conn.ReadFrom(buffer)
-			if err != nil {
-				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-					// Timeout, just continue
-					continue
-				}
-				fmt.Printf("Error reading ICMP response: %v\n", err)
-				continue
-			}
-			
-			// Parse message
-			msg, err := icmp.ParseMessage(ipv4.ICMPTypeEchoReply.Protocol(), buffer[:n])
-			if err != nil {
-				fmt.Printf("Error parsing ICMP message: %v\n", err)
-				continue
-			}
-			
-			// Check if it's an echo reply
-			if msg.Type != ipv4.ICMPTypeEchoReply {
-				continue
-			}
-			
-			// Get details from echo reply
-			reply, ok := msg.Body.(*icmp.Echo)
-			if !ok {
-				continue
-			}
-			
-			// Identify target by IP
-			target := addr.String()
-			if host, _, err := net.SplitHostPort(target); err == nil {
-				target = host
-			}
-			
-			// Find the matching target in our list
-			var matchedTarget string
-			for _, t := range p.targets {
-				// For IP addresses in CIDR range, direct comparison should work
-				if t == target {
-					matchedTarget = t
-					break
-				}
-				
-				// For hostnames, try to resolve and compare
-				if ips, err := net.LookupIP(t); err == nil {
-					for _, ip := range ips {
-						if ip.String() == target {
-							matchedTarget = t
-							break
-						}
-					}
-				}
-				
-				if matchedTarget != "" {
-					break
-				}
-			}
-			
-			if matchedTarget == "" {
-				// If we still don't have a match, use the IP as the target
-				// This ensures we catch all responses in CIDR ranges
-				matchedTarget = target
-			}
-			
-			// Process response
-			p.mutex.Lock()
-			result := p.results[matchedTarget]
-			if result == nil {
-				// Create a new result entry for this IP if it doesn't exist
-				// This happens when we receive responses from IPs not in our original targets list
-				result = &Result{
-					Target: matchedTarget,
-					RTTs:   make([]time.Duration, 0, p.config.Count),
-					MinRTT: time.Duration(math.MaxInt64),
-					MaxRTT: 0,
-					Sent:   1, // Assume we sent 1 since we got a response
-				}
-				p.results[matchedTarget] = result
-			}
-			
-			// Calculate RTT
-			rtt := time.Since(time.Now().Add(-p.config.Timeout)) // Approximate RTT
-			
-			// Update statistics
-			result.Received++
-			result.RTTs = append(result.RTTs, rtt)
-			
-			if rtt < result.MinRTT {
-				result.MinRTT = rtt
-			}
-			if rtt > result.MaxRTT {
-				result.MaxRTT = rtt
-			}
-			
-			// Print result
-			if !p.config.Quiet && !p.config.UnreachableOnly {
-				fmt.Printf("%s : [%d], %v\n", matchedTarget, reply.Seq, rtt)
-			}
-			p.mutex.Unlock()
+		}
+
+		// Wait before sending next probe
+		if seq < count {
+			time.Sleep(p.config.Interval)
+		}
+	}
+
+	p.mutex.Lock()
+	p.printUnreachable(result)
+	p.mutex.Unlock()
+}
+
+// recordOutcome updates the statistics of result with the outcome of one probe
+func (p *Pinger) recordOutcome(result *Result, seq int, outcome Outcome) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	target := result.host()
+
+	if outcome.Err != nil {
+		if isUnresolved(outcome.Err) {
+			result.Unresolved = true
+		}
+		if errors.Is(outcome.Err, ErrExcluded) {
+			result.excluded = true
+		}
+		if !p.config.Quiet {
+			fmt.Printf("%s%s : %v\n", p.linePrefix(), target, outcome.Err)
+		}
+		return
+	}
+
+	result.Sent++
+
+	if outcome.Spoofed > 0 {
+		result.Spoofed += outcome.Spoofed
+		if !p.config.Quiet {
+			fmt.Printf("%s%s : [%d], %d spoofed replies ignored\n", p.linePrefix(), target, seq, outcome.Spoofed)
+		}
+	}
+
+	if outcome.Status != StatusAlive {
+		result.History = append(result.History, -1)
+		if p.fpingOutput() {
+			p.printFpingProbe(result, seq, outcome)
+			return
+		}
+		if !p.config.Quiet && !p.config.AliveOnly {
+			fmt.Printf("%s%s : timeout\n", p.linePrefix(), target)
+		}
+		return
+	}
+
+	result.Received++
+	result.RTTs = append(result.RTTs, outcome.RTT)
+	result.History = append(result.History, outcome.RTT)
+	var ttlChange string
+	if outcome.Header != nil {
+		if n := len(result.Headers); n > 0 && result.Headers[n-1].TTL != outcome.Header.TTL {
+			ttlChange = fmt.Sprintf("ttl changed from %d to %d, the route may have changed", result.Headers[n-1].TTL, outcome.Header.TTL)
+		}
+		result.Headers = append(result.Headers, *outcome.Header)
+	}
+	if outcome.HTTP != nil {
+		result.HTTPTimings = append(result.HTTPTimings, *outcome.HTTP)
+	}
+	if outcome.TLS != nil {
+		result.TLS = outcome.TLS
+	}
+	if outcome.Warning != "" {
+		result.Warning = outcome.Warning
+	}
+	if outcome.Source != "" {
+		if result.OtherSources == nil {
+			result.OtherSources = make(map[string]int)
+		}
+		result.OtherSources[outcome.Source]++
+	}
+	if outcome.Rcode != "" {
+		if result.Rcodes == nil {
+			result.Rcodes = make(map[string]int)
+		}
+		result.Rcodes[outcome.Rcode]++
+	}
+	if outcome.RTT < result.MinRTT {
+		result.MinRTT = outcome.RTT
+	}
+	if outcome.RTT > result.MaxRTT {
+		result.MaxRTT = outcome.RTT
+	}
+
+	if p.fpingOutput() {
+		p.printFpingProbe(result, seq, outcome)
+		return
+	}
+	if !p.config.Quiet && !p.config.UnreachableOnly {
+		prefix := p.linePrefix()
+		if outcome.Reply != "" {
+			fmt.Printf("%s%s : [%d], %v (%s)\n", prefix, target, seq, outcome.RTT, outcome.Reply)
+		} else {
+			fmt.Printf("%s%s : [%d], %v\n", prefix, target, seq, outcome.RTT)
+		}
+		if outcome.Warning != "" {
+			fmt.Printf("%s%s : WARNING %s\n", prefix, target, outcome.Warning)
+		}
+		if ttlChange != "" {
+			fmt.Printf("%s%s : [%d], %s\n", prefix, target, seq, ttlChange)
 		}
 	}
 }
@@ -328,17 +494,13 @@ func (p *Pinger) listener() {
 // printSummary prints a summary of the ping results
 func (p *Pinger) printSummary() {
 	fmt.Println("\n--- GoPing Summary ---")
-	
+
 	var totalSent, totalReceived int
 	var printedTargets int
-	
-	// First print results for the original targets
-	for _, target := range p.targets {
-		result := p.results[target]
-		if result == nil {
-			continue
-		}
-		
+
+	for _, result := range p.Results() {
+		target := result.Name()
+
 		// Skip printing based on AliveOnly or UnreachableOnly flags
 		if (p.config.AliveOnly && result.Received == 0) || (p.config.UnreachableOnly && result.Received > 0) {
 			// Still count in totals
@@ -346,9 +508,9 @@ func (p *Pinger) printSummary() {
 			totalReceived += result.Received
 			continue
 		}
-		
+
 		printedTargets++
-		
+
 		if result.Received > 0 {
 			// Calculate average RTT
 			var sum time.Duration
@@ -356,7 +518,7 @@ func (p *Pinger) printSummary() {
 				sum += rtt
 			}
 			result.AvgRTT = sum / time.Duration(result.Received)
-			
+
 			// Calculate standard deviation
 			if result.Received > 1 {
 				var sumSquaredDiff float64
@@ -367,72 +529,45 @@ func (p *Pinger) printSummary() {
 				stdDev := math.Sqrt(sumSquaredDiff / float64(result.Received-1))
 				result.StdDevRTT = time.Duration(stdDev)
 			}
-			
+
 			lossPercent := float64(result.Sent-result.Received) / float64(result.Sent) * 100
-			
+
 			fmt.Printf("%s : %d/%d packets, %0.1f%% loss, min/avg/max/stddev = %v/%v/%v/%v\n",
 				target, result.Received, result.Sent, lossPercent,
 				result.MinRTT, result.AvgRTT, result.MaxRTT, result.StdDevRTT)
+			if len(result.Headers) > 0 {
+				fmt.Printf("%s : %s\n", target, summarizeTTL(result.Headers))
+			}
+			if ipid := AnalyzeIPID(result.Headers); ipid.MultipleHosts() {
+				fmt.Printf("%s : %s\n", target, ipid)
+			}
+			if len(result.HTTPTimings) > 0 {
+				fmt.Printf("%s : %s\n", target, summarizeHTTP(result.HTTPTimings))
+			}
+			if len(result.Rcodes) > 0 {
+				fmt.Printf("%s : %s\n", target, summarizeRcodes(result.Rcodes))
+			}
+			if result.TLS != nil {
+				fmt.Printf("%s : %s, %s, certificate expires %s\n", target,
+					result.TLS.Version, result.TLS.CipherSuite, result.TLS.NotAfter.Format("2006-01-02"))
+			}
+			for _, source := range slices.Sorted(maps.Keys(result.OtherSources)) {
+				fmt.Printf("%s : %d replies from other address %s\n", target, result.OtherSources[source], source)
+			}
+			if result.Warning != "" {
+				fmt.Printf("%s : WARNING %s\n", target, result.Warning)
+			}
 		} else {
 			fmt.Printf("%s : 0/%d packets, 100%% loss\n", target, result.Sent)
 		}
-		
-		totalSent += result.Sent
-		totalReceived += result.Received
-	}
-	
-	// Then print results for any additional IPs that responded but weren't in the original targets
-	for ip, result := range p.results {
-		// Skip IPs that were in the original targets list
-		found := false
-		for _, target := range p.targets {
-			if target == ip {
-				found = true
-				break
-			}
+		if result.Spoofed > 0 {
+			fmt.Printf("%s : WARNING %d spoofed replies ignored\n", target, result.Spoofed)
 		}
-		if found {
-			continue
-		}
-		
-		// Skip printing based on flags
-		if (p.config.AliveOnly && result.Received == 0) || (p.config.UnreachableOnly && result.Received > 0) {
-			// Still count in totals
-			totalSent += result.Sent
-			totalReceived += result.Received
-			continue
-		}
-		
-		printedTargets++
-		
-		// Calculate average RTT
-		var sum time.Duration
-		for _, rtt := range result.RTTs {
-			sum += rtt
-		}
-		result.AvgRTT = sum / time.Duration(result.Received)
-		
-		// Calculate standard deviation
-		if result.Received > 1 {
-			var sumSquaredDiff float64
-			for _, rtt := range result.RTTs {
-				diff := float64(rtt - result.AvgRTT)
-				sumSquaredDiff += diff * diff
-			}
-			stdDev := math.Sqrt(sumSquaredDiff / float64(result.Received-1))
-			result.StdDevRTT = time.Duration(stdDev)
-		}
-		
-		lossPercent := 0.0 // These are IPs that responded so loss is 0%
-		
-		fmt.Printf("%s : %d/%d packets, %0.1f%% loss, min/avg/max/stddev = %v/%v/%v/%v\n",
-			ip, result.Received, result.Sent, lossPercent,
-			result.MinRTT, result.AvgRTT, result.MaxRTT, result.StdDevRTT)
-		
+
 		totalSent += result.Sent
 		totalReceived += result.Received
 	}
-	
+
 	// Overall summary
 	if printedTargets > 0 {
 		totalLossPercent := float64(totalSent-totalReceived) / float64(totalSent) * 100
@@ -445,4 +580,24 @@ func (p *Pinger) printSummary() {
 	} else {
 		fmt.Println("\nNo targets to ping.")
 	}
-} 
\ No newline at end of file
+
+	p.printGroups()
+	p.printUnsolicited()
+}
+
+// Unsolicited returns the replies that matched no probe of the run, such as
+// echo replies meant for other ping processes
+func (p *Pinger) Unsolicited() []Unsolicited {
+	return p.unsolicited
+}
+
+// printUnsolicited prints the replies that were not counted for any target
+func (p *Pinger) printUnsolicited() {
+	if len(p.unsolicited) == 0 {
+		return
+	}
+	fmt.Println("\n--- Unsolicited replies ---")
+	for _, reply := range p.unsolicited {
+		fmt.Printf("%s : %d replies, %s\n", reply.Source, reply.Count, reply.Reason)
+	}
+}
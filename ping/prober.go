@@ -0,0 +1,189 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultScheme is the probe type used for targets without a URL scheme
+const DefaultScheme = "icmp"
+
+var (
+	// ErrUnresolved is wrapped by the errors of probes whose target could
+	// not be resolved
+	ErrUnresolved = errors.New("cannot resolve")
+	// ErrExcluded is wrapped by the errors of probes that were not sent
+	// because the target resolved to an excluded address
+	ErrExcluded = errors.New("resolves to excluded address")
+	// ErrUnknownScheme is wrapped by the error of Run for a target with a
+	// scheme no prober is registered for
+	ErrUnknownScheme = errors.New("unknown probe type")
+)
+
+// ProbeStatus describes how a target answered a single probe
+type ProbeStatus int
+
+const (
+	// StatusUnreachable means nothing came back before the timeout
+	StatusUnreachable ProbeStatus = iota
+	// StatusAlive means the target answered the probe
+	StatusAlive
+)
+
+// Outcome is the result of a single probe
+type Outcome struct {
+	Status ProbeStatus
+	RTT    time.Duration
+	// Reply describes what proved the target alive, e.g. "port unreachable"
+	Reply string
+	// Err is set when the probe could not be sent at all
+	Err error
+	// HTTP holds the request phases of HTTP probes
+	HTTP *HTTPTiming
+	// Rcode is the response code of DNS probes, e.g. NOERROR or NXDOMAIN
+	Rcode string
+	// TLS describes the session negotiated by TLS probes
+	TLS *TLSInfo
+	// Warning flags a target that answered but needs attention, e.g. an
+	// expiring certificate
+	Warning string
+	// Spoofed counts replies rejected because of a missing or invalid tag
+	Spoofed int
+	// Header holds the IP header fields of ICMP echo replies
+	Header *ReplyHeader
+	// Source is the address the reply came from if it is not the probed one
+	Source string
+	// Size is the length of the reply in bytes, e.g. of the ICMP echo reply
+	Size int
+}
+
+// Prober sends one type of probe. A Pinger creates a single Prober per scheme
+// and calls Probe concurrently for all targets using that scheme.
+type Prober interface {
+	// Prepare is called once before the first probe, e.g. to open sockets
+	Prepare(config Config) error
+	// Probe sends a single probe to target and waits for the outcome. The
+	// probe counts as unreachable once ctx is done.
+	Probe(ctx context.Context, target string, seq int) Outcome
+	// Close releases whatever Prepare acquired
+	Close() error
+}
+
+// Unsolicited counts the replies from one source that matched no probe of
+// this run, for one reason
+type Unsolicited struct {
+	Source string
+	Reason string
+	Count  int
+}
+
+// UnsolicitedReporter is implemented by probers that can receive replies
+// they never asked for, e.g. echo replies meant for another ping process
+type UnsolicitedReporter interface {
+	// Unsolicited returns the unmatched replies seen so far
+	Unsolicited() []Unsolicited
+}
+
+// targetIndexKey is the context key of the target index
+type targetIndexKey struct{}
+
+// withTargetIndex returns ctx carrying the index of the probed target in the run
+func withTargetIndex(ctx context.Context, index int) context.Context {
+	return context.WithValue(ctx, targetIndexKey{}, index)
+}
+
+// targetIndex returns the index of the probed target, 0 if unknown
+func targetIndex(ctx context.Context) int {
+	index, _ := ctx.Value(targetIndexKey{}).(int)
+	return index
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = make(map[string]func() Prober)
+)
+
+// Register makes a probe type available to targets written as scheme://...
+// It panics if the scheme is already registered, like database/sql drivers.
+func Register(scheme string, factory func() Prober) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	if factory == nil {
+		panic("ping: Register factory is nil")
+	}
+	if _, exists := registry[scheme]; exists {
+		panic("ping: Register called twice for scheme " + scheme)
+	}
+	registry[scheme] = factory
+}
+
+// Schemes returns the registered schemes in sorted order
+func Schemes() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	schemes := make([]string, 0, len(registry))
+	for scheme := range registry {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// newProber creates a Prober for scheme from the registry
+func newProber(scheme string) (Prober, error) {
+	registryMutex.RLock()
+	factory := registry[scheme]
+	registryMutex.RUnlock()
+
+	if factory == nil {
+		return nil, fmt.Errorf("%w %q, registered types are %s",
+			ErrUnknownScheme, scheme, strings.Join(Schemes(), ", "))
+	}
+	return factory(), nil
+}
+
+// trimScheme strips the scheme:// prefix from target, if any
+func trimScheme(target string) string {
+	if i := strings.Index(target, "://"); i > 0 {
+		return target[i+3:]
+	}
+	return target
+}
+
+// hostOf returns the host of target without scheme, port or path, e.g.
+// "example.com" for "icmp://example.com:7"
+func hostOf(target string) string {
+	host := trimScheme(target)
+	if i := strings.IndexByte(host, '/'); i >= 0 {
+		host = host[:i]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.Trim(host, "[]")
+}
+
+// isUnresolved reports whether err means the target could not be resolved
+func isUnresolved(err error) bool {
+	return errors.Is(err, ErrUnresolved)
+}
+
+// resolveError returns err marked with ErrUnresolved if it is a failed lookup
+// of a standard library client, nil otherwise. A lookup cut short by ctx is a
+// lost probe rather than a name that does not resolve.
+func resolveError(ctx context.Context, err error) error {
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrUnresolved, err)
+}
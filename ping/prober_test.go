@@ -0,0 +1,396 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/windows-fping/goping/target"
+)
+
+// heartbeatProber stands in for a custom probe type registered by a user
+type heartbeatProber struct {
+	prepared bool
+	closed   bool
+}
+
+var lastHeartbeat *heartbeatProber
+
+func init() {
+	Register("heartbeat", func() Prober {
+		lastHeartbeat = &heartbeatProber{}
+		return lastHeartbeat
+	})
+}
+
+func (h *heartbeatProber) Prepare(config Config) error {
+	h.prepared = true
+	return nil
+}
+
+func (h *heartbeatProber) Close() error {
+	h.closed = true
+	return nil
+}
+
+// Probe answers every even sequence number after seq milliseconds
+func (h *heartbeatProber) Probe(ctx context.Context, target string, seq int) Outcome {
+	if seq%2 == 1 {
+		return Outcome{Status: StatusUnreachable}
+	}
+	return Outcome{Status: StatusAlive, RTT: time.Duration(seq) * time.Millisecond}
+}
+
+// mustParseSpecs parses target specs into a set or fails the test
+func mustParseSpecs(t *testing.T, specs ...string) target.Iterator {
+	t.Helper()
+	parsed, err := target.ParseAll(specs)
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	set, err := target.NewSet(parsed)
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+	return set.All()
+}
+
+func TestPingerUsesRegisteredProber(t *testing.T) {
+	config := Config{
+		Count:   4,
+		Timeout: time.Second,
+		Quiet:   true,
+	}
+	targets := mustParseSpecs(t, "heartbeat://core-1", "HEARTBEAT://core-2;label=spare")
+	pinger := NewPinger(targets, config)
+	if pinger.NeedsRawSocket(target.Collect(targets)) {
+		t.Errorf("NeedsRawSocket() = true, want false without ICMP targets")
+	}
+	if err := pinger.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !lastHeartbeat.prepared || !lastHeartbeat.closed {
+		t.Errorf("prober prepared = %v, closed = %v, want both", lastHeartbeat.prepared, lastHeartbeat.closed)
+	}
+
+	results := pinger.Results()
+	if len(results) != 2 {
+		t.Fatalf("Results() returned %d results, want 2", len(results))
+	}
+	for _, result := range results {
+		if result.Sent != 4 || result.Received != 2 {
+			t.Errorf("%s: %d/%d received, want 2/4", result.Target, result.Received, result.Sent)
+		}
+		want := []time.Duration{2 * time.Millisecond, 4 * time.Millisecond}
+		if !reflect.DeepEqual(result.RTTs, want) {
+			t.Errorf("%s: RTTs = %v, want %v", result.Target, result.RTTs, want)
+		}
+	}
+}
+
+func TestNeedsRawSocket(t *testing.T) {
+	tests := []struct {
+		name     string
+		scheme   string
+		specs    []string
+		expected bool
+	}{
+		{name: "ICMP sweep", specs: []string{"10.0.0.0/8"}, expected: true},
+		{name: "UDP sweep", scheme: "udp", specs: []string{"10.0.0.0/8"}},
+		{name: "ICMP target among UDP", scheme: "udp", specs: []string{"10.0.0.0/8", "icmp://core-rtr"}, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			specs, err := target.ParseAll(test.specs)
+			if err != nil {
+				t.Fatalf("ParseAll() error = %v", err)
+			}
+			pinger := NewPinger(nil, Config{DefaultScheme: test.scheme})
+			if got := pinger.NeedsRawSocket(specs); got != test.expected {
+				t.Errorf("NeedsRawSocket() = %v, want %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestHostOf(t *testing.T) {
+	tests := []struct {
+		target   string
+		expected string
+	}{
+		{target: "example.com", expected: "example.com"},
+		{target: "example.com:8080", expected: "example.com"},
+		{target: "icmp://10.0.0.1:7", expected: "10.0.0.1"},
+		{target: "icmp://core-rtr/path", expected: "core-rtr"},
+		{target: "[2001:db8::1]:7", expected: "2001:db8::1"},
+		{target: "2001:db8::1", expected: "2001:db8::1"},
+	}
+
+	for _, test := range tests {
+		if got := hostOf(test.target); got != test.expected {
+			t.Errorf("hostOf(%q) = %q, want %q", test.target, got, test.expected)
+		}
+	}
+}
+
+func TestPingerUnknownScheme(t *testing.T) {
+	pinger := NewPinger(mustParseSpecs(t, "gopher://example.com"), Config{Count: 1, Timeout: time.Second})
+	if err := pinger.Run(); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("Run() error = %v, want unknown probe type", err)
+	}
+}
+
+func TestPingerTotals(t *testing.T) {
+	// Nothing listens on the DNS server, so the name cannot be resolved
+	resolver := NewResolver()
+	resolver.Server = "127.0.0.1:1"
+	resolver.Timeout = time.Second
+	config := Config{
+		Count:    2,
+		Timeout:  time.Second,
+		Resolver: resolver,
+	}
+	pinger := NewPinger(mustParseSpecs(t, "heartbeat://core-1", "heartbeat://core-2;count=1", "udp://missing.example:53"), config)
+	var err error
+	captureStdout(t, func() { err = pinger.Run() })
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	expected := Totals{Targets: 3, Alive: 1, Unresolved: 1}
+	if got := pinger.Totals(); got != expected {
+		t.Errorf("Totals() = %+v, want %+v", got, expected)
+	}
+	if len(pinger.Results()) != 0 {
+		t.Errorf("Results() kept %d results, want none without a summary", len(pinger.Results()))
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register() did not panic for a duplicate scheme")
+		}
+	}()
+	Register("icmp", func() Prober { return &ICMPProber{} })
+}
+
+func TestPingerTargetOptions(t *testing.T) {
+	config := Config{
+		Count:   4,
+		Timeout: time.Second,
+		Quiet:   true,
+	}
+	pinger := NewPinger(mustParseSpecs(t, "heartbeat://core-1;count=2;label=core-rtr"), config)
+	if err := pinger.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	result := pinger.Results()[0]
+	if result.Sent != 2 || result.Received != 1 {
+		t.Errorf("%d/%d received, want 1/2 with count=2", result.Received, result.Sent)
+	}
+	if result.Name() != "heartbeat://core-1 [core-rtr]" {
+		t.Errorf("Name() = %q, want the label next to the target", result.Name())
+	}
+}
+
+func TestPingerAllAddresses(t *testing.T) {
+	config := Config{
+		Count:        2,
+		Timeout:      time.Second,
+		Quiet:        true,
+		AllAddresses: true,
+	}
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(hosts, []byte("10.0.0.1 pool.example\n10.0.0.2 pool.example\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config.Resolver = NewResolver()
+	if err := config.Resolver.LoadHosts(hosts); err != nil {
+		t.Fatalf("LoadHosts() error = %v", err)
+	}
+
+	pinger := NewPinger(mustParseSpecs(t, "heartbeat://pool.example:7", "heartbeat://10.0.0.9", "https://pool.example"), config)
+	if err := pinger.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var names []string
+	for _, result := range pinger.Results() {
+		names = append(names, result.Name())
+	}
+	expected := []string{
+		"heartbeat://10.0.0.1:7 (pool.example)",
+		"heartbeat://10.0.0.2:7 (pool.example)",
+		"heartbeat://10.0.0.9",
+		"https://pool.example",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Results() = %v, want %v", names, expected)
+	}
+
+	groups := pinger.Groups()
+	if len(groups) != 1 || groups[0].Name != "pool.example" || len(groups[0].Results) != 2 {
+		t.Fatalf("Groups() = %+v, want pool.example with 2 addresses", groups)
+	}
+	if !groups[0].AnyAlive() || !groups[0].AllAlive() || groups[0].State() != "all alive" {
+		t.Errorf("group state = %s, want all alive", groups[0].State())
+	}
+}
+
+func TestPingerAllAddressesSkipsKnown(t *testing.T) {
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	content := "10.0.0.1 pool.example\n10.0.0.2 pool.example\n10.0.0.3 pool.example other.example\n"
+	if err := os.WriteFile(hosts, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewResolver()
+	if err := resolver.LoadHosts(hosts); err != nil {
+		t.Fatalf("LoadHosts() error = %v", err)
+	}
+	exclude, err := target.NewExcludeList([]string{"10.0.0.1"})
+	if err != nil {
+		t.Fatalf("NewExcludeList() error = %v", err)
+	}
+	specs, err := target.ParseAll([]string{"10.0.0.2/32", "pool.example", "other.example"})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	set, err := target.NewSet(specs)
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+
+	config := Config{
+		Count:         1,
+		Timeout:       time.Second,
+		KeepResults:   true,
+		Quiet:         true,
+		AllAddresses:  true,
+		DefaultScheme: "heartbeat",
+		Resolver:      resolver,
+		Exclude:       exclude,
+		Set:           set,
+	}
+	pinger := NewPinger(set.All(), config)
+	if err := pinger.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// 10.0.0.1 is excluded, 10.0.0.2 is a target of its own and 10.0.0.3 is
+	// probed for pool.example already
+	var names []string
+	for _, result := range pinger.Results() {
+		names = append(names, result.Name())
+	}
+	expected := []string{"10.0.0.2", "10.0.0.3 (pool.example)"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Results() = %v, want %v", names, expected)
+	}
+}
+
+func TestGroupState(t *testing.T) {
+	tests := []struct {
+		name     string
+		received []int
+		expected string
+	}{
+		{name: "All alive", received: []int{1, 2}, expected: "all alive"},
+		{name: "Some alive", received: []int{0, 2}, expected: "some alive"},
+		{name: "None alive", received: []int{0, 0}, expected: "none alive"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := &Group{Name: "pool.example"}
+			for _, received := range test.received {
+				group.Results = append(group.Results, &Result{Received: received})
+			}
+			if got := group.State(); got != test.expected {
+				t.Errorf("State() = %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestPingerExcludesResolvedAddress(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() error = %v", err)
+	}
+	defer server.Close()
+	var received atomic.Int32
+	go func() {
+		buffer := make([]byte, 1500)
+		for {
+			if _, _, err := server.ReadFromUDP(buffer); err != nil {
+				return
+			}
+			received.Add(1)
+		}
+	}()
+
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(hosts, []byte("127.0.0.1 excluded.test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exclude, err := target.NewExcludeList([]string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("NewExcludeList() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		raw    bool
+	}{
+		{name: "UDP", target: "udp://excluded.test:" + strconv.Itoa(server.LocalAddr().(*net.UDPAddr).Port)},
+		{name: "ICMP", target: "icmp://excluded.test", raw: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolver := NewResolver()
+			if err := resolver.LoadHosts(hosts); err != nil {
+				t.Fatalf("LoadHosts() error = %v", err)
+			}
+			config := Config{Count: 3, Timeout: time.Second, Quiet: true, Resolver: resolver, Exclude: exclude}
+			if test.raw {
+				socket, err := OpenSocket()
+				if err != nil {
+					t.Skipf("cannot open a raw ICMP socket: %v", err)
+				}
+				defer socket.Close()
+				config.Socket = socket
+			}
+
+			// The name is skipped instead of probed or counted as unreachable
+			pinger := NewPinger(mustParseSpecs(t, test.target), config)
+			captureStdout(t, func() {
+				if err := pinger.Run(); err != nil {
+					t.Errorf("Run() error = %v", err)
+				}
+			})
+			if results := pinger.Results(); len(results) != 0 {
+				t.Errorf("Results() = %+v, want none", results)
+			}
+			if totals := pinger.Totals(); totals != (Totals{}) {
+				t.Errorf("Totals() = %+v, want none counted", totals)
+			}
+		})
+	}
+	time.Sleep(10 * time.Millisecond)
+	if got := received.Load(); got != 0 {
+		t.Errorf("the excluded address received %d datagrams", got)
+	}
+}
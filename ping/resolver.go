@@ -0,0 +1,396 @@
+package ping
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// DefaultResolveTimeout bounds a single hostname lookup
+	DefaultResolveTimeout = 5 * time.Second
+	// systemTTL is how long answers of the system resolver are cached, since
+	// it does not report the TTL of the records
+	systemTTL = 5 * time.Minute
+	// minTTL keeps records with a tiny TTL from being looked up on every probe
+	minTTL = 5 * time.Second
+	// negativeTTL is how long a failed lookup is cached
+	negativeTTL = 30 * time.Second
+	// resolveWorkers is the number of lookups ResolveAll runs at once
+	resolveWorkers = 32
+)
+
+// resolution is a cached forward or reverse lookup. ready is closed once ips and err are set,
+// so concurrent lookups of the same name wait for a single query.
+type resolution struct {
+	ips     []net.IP
+	names   []string
+	err     error
+	expires time.Time
+	ready   chan struct{}
+	// refreshing is set once the expired entry is being resolved again
+	refreshing bool
+}
+
+// Resolver resolves hostnames to IPv4 addresses and caches the answers until
+// their TTL expires, so a name is looked up once per TTL rather than once per
+// probe. Names from a hosts file take precedence over DNS.
+type Resolver struct {
+	// Server is the host[:port] of the DNS server to query, port 53 if
+	// omitted. The system resolver is used if it is empty.
+	Server string
+	// Timeout bounds a single lookup, DefaultResolveTimeout if zero
+	Timeout time.Duration
+
+	hosts map[string][]net.IP
+	// names maps the addresses of the hosts file to their names
+	names   map[string][]string
+	cache   map[string]*resolution
+	reverse map[string]*resolution
+	mutex   sync.Mutex
+	// now returns the current time, replaced by tests
+	now func() time.Time
+}
+
+// NewResolver creates a resolver that uses the system resolver
+func NewResolver() *Resolver {
+	return &Resolver{
+		hosts:   make(map[string][]net.IP),
+		names:   make(map[string][]string),
+		cache:   make(map[string]*resolution),
+		reverse: make(map[string]*resolution),
+		now:     time.Now,
+	}
+}
+
+// LoadHosts adds the IPv4 entries of a file in hosts file format, an address
+// followed by one or more names per line. IPv6 entries are skipped.
+func (r *Resolver) LoadHosts(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		if ip == nil || len(fields) < 2 {
+			return fmt.Errorf("%s:%d: expected an address followed by host names", filename, line)
+		}
+		if ip.To4() == nil {
+			continue
+		}
+		for _, name := range fields[1:] {
+			name = strings.ToLower(strings.TrimSuffix(name, "."))
+			r.hosts[name] = append(r.hosts[name], ip.To4())
+			r.names[ip.String()] = append(r.names[ip.String()], name)
+		}
+	}
+	return scanner.Err()
+}
+
+// Lookup returns the IPv4 addresses of host. Addresses are returned as they
+// are, everything else comes from the hosts file or the cache. Missing names
+// are resolved while the caller waits, expired ones in the background.
+func (r *Resolver) Lookup(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() == nil {
+			return nil, fmt.Errorf("%s is not an IPv4 address", host)
+		}
+		return []net.IP{ip.To4()}, nil
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(host, "."))
+	if ips := r.hosts[name]; len(ips) > 0 {
+		return ips, nil
+	}
+
+	entry := r.cached(r.cache, name, func(entry *resolution) {
+		entry.ips, entry.expires, entry.err = r.resolve(name)
+	})
+
+	select {
+	case <-entry.ready:
+		return entry.ips, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Reverse returns the name of ip from its PTR record, or from the hosts file
+// if it is listed there. Answers are cached like those of Lookup.
+func (r *Resolver) Reverse(ctx context.Context, ip net.IP) (string, error) {
+	addr := ip.String()
+	if names := r.names[addr]; len(names) > 0 {
+		return names[0], nil
+	}
+
+	entry := r.cached(r.reverse, addr, func(entry *resolution) {
+		entry.names, entry.expires, entry.err = r.resolvePTR(addr)
+	})
+
+	select {
+	case <-entry.ready:
+		if entry.err != nil {
+			return "", entry.err
+		}
+		return entry.names[0], nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// resolveAddress returns address with its host resolved by r, so that probes
+// dialing through the standard library still use the hosts file, the DNS
+// server and the cache. Addresses are returned as they are, and so is
+// everything if r is nil. A lookup is reported to the httptrace.ClientTrace
+// of ctx, if any, and a failed one is returned as a *net.DNSError like the
+// standard library does.
+func (r *Resolver) resolveAddress(ctx context.Context, address string) (string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || r == nil || net.ParseIP(host) != nil {
+		return address, nil
+	}
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+	ips, err := r.Lookup(ctx, host)
+	if trace != nil && trace.DNSDone != nil {
+		trace.DNSDone(httptrace.DNSDoneInfo{Err: err})
+	}
+	if err != nil {
+		var dnsErr *net.DNSError
+		if ctx.Err() != nil || errors.As(err, &dnsErr) {
+			return "", err
+		}
+		return "", &net.DNSError{Err: err.Error(), Name: host, UnwrapErr: err}
+	}
+	return net.JoinHostPort(ips[0].String(), port), nil
+}
+
+// dialContext connects to address like net.Dialer, but resolves its host with r
+func (r *Resolver) dialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	address, err := r.resolveAddress(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, address)
+}
+
+// cached returns the entry for key in cache. A missing entry is filled in by
+// resolve in the background, callers wait for its ready channel as long as
+// their context allows. An expired entry is still returned while it is
+// resolved again, so probes never wait for DNS once a name has been resolved.
+func (r *Resolver) cached(cache map[string]*resolution, key string, resolve func(entry *resolution)) *resolution {
+	r.mutex.Lock()
+	entry := cache[key]
+	if entry == nil {
+		entry = &resolution{ready: make(chan struct{})}
+		cache[key] = entry
+		r.mutex.Unlock()
+
+		go func() {
+			resolve(entry)
+			close(entry.ready)
+		}()
+		return entry
+	}
+
+	select {
+	case <-entry.ready:
+		if r.now().After(entry.expires) && !entry.refreshing {
+			entry.refreshing = true
+			go r.refresh(cache, key, resolve)
+		}
+	default:
+		// Another probe is resolving the key already
+	}
+	r.mutex.Unlock()
+	return entry
+}
+
+// refresh resolves key again and replaces its entry once the answer is in
+func (r *Resolver) refresh(cache map[string]*resolution, key string, resolve func(entry *resolution)) {
+	entry := &resolution{ready: make(chan struct{})}
+	resolve(entry)
+	close(entry.ready)
+
+	r.mutex.Lock()
+	cache[key] = entry
+	r.mutex.Unlock()
+}
+
+// ResolveAll looks up every hostname in parallel and caches the answers, so
+// that probing does not wait for DNS
+func (r *Resolver) ResolveAll(hosts []string) {
+	names := make(chan string)
+	var wg sync.WaitGroup
+	for range resolveWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				r.Lookup(context.Background(), name)
+			}
+		}()
+	}
+
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		names <- host
+	}
+	close(names)
+	wg.Wait()
+}
+
+// resolve queries the configured server for name and returns its addresses
+// and when they expire
+func (r *Resolver) resolve(name string) ([]net.IP, time.Time, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	var ips []net.IP
+	var err error
+	ttl := systemTTL
+	if r.Server != "" {
+		ips, ttl, err = r.query(ctx, name)
+	} else {
+		ips, err = net.DefaultResolver.LookupIP(ctx, "ip4", name)
+	}
+	if err == nil && len(ips) == 0 {
+		err = fmt.Errorf("no IPv4 address for %s", name)
+	}
+	if err != nil {
+		return nil, r.now().Add(negativeTTL), err
+	}
+	return ips, r.now().Add(max(ttl, minTTL)), nil
+}
+
+// resolvePTR queries the configured server for the name of addr and returns
+// its names and when they expire
+func (r *Resolver) resolvePTR(addr string) ([]string, time.Time, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	var names []string
+	var err error
+	ttl := systemTTL
+	if r.Server != "" {
+		names, ttl, err = r.queryPTR(ctx, addr)
+	} else {
+		names, err = net.DefaultResolver.LookupAddr(ctx, addr)
+	}
+	if err == nil && len(names) == 0 {
+		err = fmt.Errorf("no PTR record for %s", addr)
+	}
+	if err != nil {
+		return nil, r.now().Add(negativeTTL), err
+	}
+	for i, name := range names {
+		names[i] = strings.TrimSuffix(name, ".")
+	}
+	return names, r.now().Add(max(ttl, minTTL)), nil
+}
+
+// context returns a context that ends after the lookup timeout
+func (r *Resolver) context() (context.Context, context.CancelFunc) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultResolveTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// server returns Server with the default port added if it has none
+func (r *Resolver) server() string {
+	if _, _, err := net.SplitHostPort(r.Server); err != nil {
+		return net.JoinHostPort(strings.Trim(r.Server, "[]"), "53")
+	}
+	return r.Server
+}
+
+// query asks Server for the A records of name. The TTL of the answer is the
+// lowest TTL among the records.
+func (r *Resolver) query(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+	msg, err := r.exchange(ctx, name, dnsmessage.TypeA)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var ips []net.IP
+	var ttl time.Duration
+	for _, answer := range msg.Answers {
+		a, ok := answer.Body.(*dnsmessage.AResource)
+		if !ok {
+			continue
+		}
+		ips = append(ips, net.IP(a.A[:]))
+		recordTTL := time.Duration(answer.Header.TTL) * time.Second
+		if len(ips) == 1 || recordTTL < ttl {
+			ttl = recordTTL
+		}
+	}
+	return ips, ttl, nil
+}
+
+// queryPTR asks Server for the PTR records of addr
+func (r *Resolver) queryPTR(ctx context.Context, addr string) ([]string, time.Duration, error) {
+	ip := net.ParseIP(addr).To4()
+	if ip == nil {
+		return nil, 0, fmt.Errorf("%s is not an IPv4 address", addr)
+	}
+	arpa := fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", ip[3], ip[2], ip[1], ip[0])
+	msg, err := r.exchange(ctx, arpa, dnsmessage.TypePTR)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var names []string
+	var ttl time.Duration
+	for _, answer := range msg.Answers {
+		ptr, ok := answer.Body.(*dnsmessage.PTRResource)
+		if !ok {
+			continue
+		}
+		names = append(names, ptr.PTR.String())
+		recordTTL := time.Duration(answer.Header.TTL) * time.Second
+		if len(names) == 1 || recordTTL < ttl {
+			ttl = recordTTL
+		}
+	}
+	return names, ttl, nil
+}
+
+// exchange sends a query to Server and fails unless the answer is NOERROR
+func (r *Resolver) exchange(ctx context.Context, name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	msg, _, err := exchangeDNS(ctx, r.server(), name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Header.RCode != dnsmessage.RCodeSuccess {
+		return nil, errors.New(rcodeName(msg.Header.RCode) + " from " + r.Server)
+	}
+	return msg, nil
+}
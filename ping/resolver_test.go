@@ -0,0 +1,223 @@
+package ping
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolverHostsFile(t *testing.T) {
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	content := "# lab overrides\n192.0.2.10 core-rtr core-rtr.lab. # primary\n192.0.2.11 core-rtr\n::1 core-rtr\n"
+	if err := os.WriteFile(hosts, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := NewResolver()
+	if err := resolver.LoadHosts(hosts); err != nil {
+		t.Fatalf("LoadHosts() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		host     string
+		expected []net.IP
+	}{
+		{name: "Override", host: "core-rtr", expected: []net.IP{net.ParseIP("192.0.2.10").To4(), net.ParseIP("192.0.2.11").To4()}},
+		{name: "Alias with trailing dot", host: "CORE-RTR.lab.", expected: []net.IP{net.ParseIP("192.0.2.10").To4()}},
+		{name: "Address", host: "198.51.100.7", expected: []net.IP{net.ParseIP("198.51.100.7").To4()}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := resolver.Lookup(context.Background(), test.host)
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("Lookup() = %v, want %v", got, test.expected)
+			}
+		})
+	}
+
+	if err := os.WriteFile(hosts, []byte("not-an-address core-rtr\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewResolver().LoadHosts(hosts); err == nil {
+		t.Errorf("LoadHosts() error = nil, want an error for an invalid address")
+	}
+}
+
+func TestResolverCachesUntilTTL(t *testing.T) {
+	server := newDNSTestServer(t)
+
+	now := time.Now()
+	resolver := NewResolver()
+	resolver.Server = server.addr
+	resolver.now = func() time.Time { return now }
+
+	// Concurrent lookups of the same name share one query
+	resolver.ResolveAll([]string{"example.test", "example.test", "nxdomain.test"})
+	for range 3 {
+		ips, err := resolver.Lookup(context.Background(), "example.test")
+		if err != nil {
+			t.Fatalf("Lookup() error = %v", err)
+		}
+		if len(ips) != 1 || !ips[0].Equal(net.IPv4(192, 0, 2, 1)) {
+			t.Errorf("Lookup() = %v, want 192.0.2.1", ips)
+		}
+	}
+	if _, err := resolver.Lookup(context.Background(), "nxdomain.test"); err == nil {
+		t.Errorf("Lookup() error = nil, want NXDOMAIN")
+	}
+	if got := server.queries.Load(); got != 2 {
+		t.Errorf("server received %d queries, want 2", got)
+	}
+
+	// The stand-in server answers with a TTL of 60 seconds. The expired
+	// answer is still served while it is refreshed in the background.
+	now = now.Add(61 * time.Second)
+	for range 3 {
+		if _, err := resolver.Lookup(context.Background(), "example.test"); err != nil {
+			t.Fatalf("Lookup() error = %v", err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for server.queries.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if got := server.queries.Load(); got != 3 {
+		t.Errorf("server received %d queries after the TTL expired, want 3", got)
+	}
+}
+
+func TestPingerShowNamesSlowLookup(t *testing.T) {
+	server := newDNSTestServer(t)
+	server.delay.Store(int64(200 * time.Millisecond))
+	resolver := NewResolver()
+	resolver.Server = server.addr
+
+	config := Config{
+		Count:     2,
+		Timeout:   time.Second,
+		ShowNames: true,
+		ShowStats: true,
+		Resolver:  resolver,
+	}
+	pinger := NewPinger(mustParseSpecs(t, "heartbeat://192.0.2.9"), config)
+	out := captureStdout(t, func() {
+		if err := pinger.Run(); err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	})
+
+	// The probes are answered before the PTR record, only the summary has the name
+	if !strings.Contains(out, "heartbeat://192.0.2.9 : [2]") {
+		t.Errorf("the probe line waited for the name:\n%s", out)
+	}
+	if !strings.Contains(out, "heartbeat://slow.test : 1/2 packets") {
+		t.Errorf("the summary does not show the name:\n%s", out)
+	}
+}
+
+func TestPingerShowNames(t *testing.T) {
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(hosts, []byte("10.0.0.1 core-rtr\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := newDNSTestServer(t)
+
+	tests := []struct {
+		name          string
+		target        string
+		showNames     bool
+		showAddresses bool
+		expected      string
+	}{
+		{name: "Name from PTR", target: "heartbeat://10.0.0.1", showNames: true, expected: "heartbeat://core-rtr"},
+		{name: "Name and address", target: "heartbeat://10.0.0.1", showNames: true, showAddresses: true, expected: "heartbeat://core-rtr (10.0.0.1)"},
+		{name: "Missing PTR", target: "heartbeat://10.0.0.2", showNames: true, expected: "heartbeat://10.0.0.2"},
+		{name: "Address of name", target: "heartbeat://core-rtr:7", showAddresses: true, expected: "heartbeat://10.0.0.1:7"},
+		{name: "Name target with both", target: "heartbeat://core-rtr:7", showNames: true, showAddresses: true, expected: "heartbeat://core-rtr:7 (10.0.0.1)"},
+		{name: "Neither", target: "heartbeat://core-rtr", expected: "heartbeat://core-rtr"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolver := NewResolver()
+			resolver.Server = server.addr
+			if err := resolver.LoadHosts(hosts); err != nil {
+				t.Fatalf("LoadHosts() error = %v", err)
+			}
+			config := Config{
+				Count:         1,
+				Timeout:       time.Second,
+				Quiet:         true,
+				ShowNames:     test.showNames,
+				ShowAddresses: test.showAddresses,
+				Resolver:      resolver,
+			}
+			pinger := NewPinger(mustParseSpecs(t, test.target), config)
+			if err := pinger.Run(); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := pinger.Results()[0].Name(); got != test.expected {
+				t.Errorf("Name() = %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestProbesUseResolver(t *testing.T) {
+	hosts := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(hosts, []byte("127.0.0.1 lab.test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewResolver()
+	if err := resolver.LoadHosts(hosts); err != nil {
+		t.Fatalf("LoadHosts() error = %v", err)
+	}
+
+	web := httptest.NewServer(http.NotFoundHandler())
+	defer web.Close()
+	secure := httptest.NewTLSServer(http.NotFoundHandler())
+	defer secure.Close()
+	dns := newDNSTestServer(t)
+
+	// lab.test is only known to the hosts file
+	port := func(addr net.Addr) string {
+		_, port, _ := net.SplitHostPort(addr.String())
+		return port
+	}
+	tests := []struct {
+		name   string
+		prober Prober
+		target string
+	}{
+		{name: "HTTP", prober: &HTTPProbe{}, target: "http://lab.test:" + port(web.Listener.Addr()) + "/"},
+		{name: "TLS", prober: &TLSProbe{}, target: "tls://lab.test:" + port(secure.Listener.Addr())},
+		{name: "DNS", prober: &DNSProbe{}, target: "dns://lab.test:" + port(dns.udp.LocalAddr()) + "/example.test"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.prober.Prepare(Config{Resolver: resolver}); err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			outcome := test.prober.Probe(ctx, test.target, 1)
+			if outcome.Err != nil || outcome.Status != StatusAlive {
+				t.Errorf("Probe() = %+v, want alive", outcome)
+			}
+		})
+	}
+}
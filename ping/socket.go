@@ -0,0 +1,194 @@
+package ping
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+var (
+	idMutex  sync.Mutex
+	idsInUse = make(map[int]bool)
+)
+
+// allocateID returns a random echo ID that no other session of this process
+// uses, so that neither other processes nor other Pingers share it
+func allocateID() (int, error) {
+	idMutex.Lock()
+	defer idMutex.Unlock()
+
+	if len(idsInUse) > 0xffff {
+		return 0, fmt.Errorf("all %d echo IDs are in use", 0x10000)
+	}
+	start := rand.IntN(0x10000)
+	for i := 0; i <= 0xffff; i++ {
+		id := (start + i) & 0xffff
+		if !idsInUse[id] {
+			idsInUse[id] = true
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("all %d echo IDs are in use", 0x10000)
+}
+
+// releaseID makes id available to new sessions
+func releaseID(id int) {
+	idMutex.Lock()
+	defer idMutex.Unlock()
+	delete(idsInUse, id)
+}
+
+// Socket is a raw ICMP socket that can be shared by several Pingers. Each
+// Pinger's ICMP prober is a session with its own echo ID, and a single
+// listener hands every reply to the session it belongs to.
+type Socket struct {
+	conn       *net.IPConn
+	sessions   map[int]*ICMPProber
+	mutex      sync.Mutex
+	done       chan struct{}
+	listenerWg sync.WaitGroup
+}
+
+// OpenSocket opens a raw ICMP socket, which requires administrator privileges
+func OpenSocket() (*Socket, error) {
+	// A plain IP connection rather than icmp.PacketConn, whose reads drop
+	// the IP header with the TTL
+	packetConn, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return nil, fmt.Errorf("error opening connection: %w", err)
+	}
+	conn := packetConn.(*net.IPConn)
+
+	s := &Socket{
+		conn:     conn,
+		sessions: make(map[int]*ICMPProber),
+		done:     make(chan struct{}),
+	}
+
+	// Start the listener goroutine
+	s.listenerWg.Add(1)
+	go func() {
+		defer s.listenerWg.Done()
+		s.listener()
+	}()
+
+	return s, nil
+}
+
+// Close stops the listener and closes the socket. Sessions still using it
+// stop receiving replies.
+func (s *Socket) Close() error {
+	close(s.done)
+
+	// Wait for listener to exit before closing the connection
+	s.listenerWg.Wait()
+	return s.conn.Close()
+}
+
+// register adds a session and returns its echo ID
+func (s *Socket) register(session *ICMPProber) (int, error) {
+	id, err := allocateID()
+	if err != nil {
+		return 0, err
+	}
+	s.mutex.Lock()
+	s.sessions[id] = session
+	s.mutex.Unlock()
+	return id, nil
+}
+
+// unregister removes the session with echo ID id
+func (s *Socket) unregister(id int) {
+	s.mutex.Lock()
+	delete(s.sessions, id)
+	s.mutex.Unlock()
+	releaseID(id)
+}
+
+// writeTo sends a packet, it is safe to call from several sessions at once
+func (s *Socket) writeTo(packet []byte, addr net.Addr) error {
+	_, err := s.conn.WriteTo(packet, addr)
+	return err
+}
+
+// listener reads ICMP responses and passes them to their sessions
+func (s *Socket) listener() {
+	buffer := make([]byte, 1500)
+
+	for {
+		select {
+		case <-s.done:
+			return
+		default:
+			// Set read deadline
+			err := s.conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			if err != nil {
+				fmt.Printf("Error setting read deadline: %v\n", err)
+				continue
+			}
+
+			// Read packet
+			// Unlike ReadFrom, ReadMsgIP keeps the IP header
+			n, _, _, addr, err := s.conn.ReadMsgIP(buffer, nil)
+			received := monotonicNow()
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					// Timeout, just continue
+					continue
+				}
+				fmt.Printf("Error reading ICMP response: %v\n", err)
+				continue
+			}
+
+			// Parse message
+			header, message := splitIPv4(buffer[:n])
+			msg, err := icmp.ParseMessage(ipv4.ICMPTypeEchoReply.Protocol(), message)
+			if err != nil {
+				fmt.Printf("Error parsing ICMP message: %v\n", err)
+				continue
+			}
+
+			// Check if it's an echo reply
+			if msg.Type != ipv4.ICMPTypeEchoReply {
+				continue
+			}
+
+			// Get details from echo reply
+			reply, ok := msg.Body.(*icmp.Echo)
+			if !ok {
+				continue
+			}
+
+			s.dispatch(addr.IP.String(), reply, header, received)
+		}
+	}
+}
+
+// dispatch hands a reply to the session with its echo ID. Replies with an
+// unknown ID belong to another process, every session counts them.
+func (s *Socket) dispatch(source string, reply *icmp.Echo, header *ReplyHeader, received time.Duration) {
+	s.mutex.Lock()
+	session := s.sessions[reply.ID]
+	var others []*ICMPProber
+	if session == nil {
+		for _, other := range s.sessions {
+			others = append(others, other)
+		}
+	}
+	s.mutex.Unlock()
+
+	if session != nil {
+		session.match(source, reply, header, received)
+		return
+	}
+	for _, other := range others {
+		other.match(source, reply, header, received)
+	}
+}
@@ -0,0 +1,133 @@
+package ping
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// DefaultCertWarnDays is how close to expiry a certificate has to be before
+// TLS probes report a warning
+const DefaultCertWarnDays = 30
+
+// TLSInfo describes the session negotiated by a TLS probe
+type TLSInfo struct {
+	Version     string
+	CipherSuite string
+	NotAfter    time.Time
+}
+
+func init() {
+	Register("tls", func() Prober { return &TLSProbe{} })
+}
+
+// TLSProbe measures the TLS handshake of an endpoint and checks its leaf certificate
+type TLSProbe struct {
+	WarnDays int
+	// RootCAs overrides the system roots used to verify the chain
+	RootCAs *x509.CertPool
+	// Resolver resolves the host of the target, the system resolver if nil
+	Resolver *Resolver
+}
+
+// Prepare takes the certificate warning window and resolver from config
+func (t *TLSProbe) Prepare(config Config) error {
+	t.WarnDays = config.CertWarnDays
+	t.Resolver = config.Resolver
+	return nil
+}
+
+// Close does nothing, every probe uses its own connection
+func (t *TLSProbe) Close() error {
+	return nil
+}
+
+// Probe performs a single handshake with the tls://host[:port] target. A
+// completed handshake counts as alive, certificate problems only add a warning.
+func (t *TLSProbe) Probe(ctx context.Context, target string, seq int) Outcome {
+	u, err := url.Parse(target)
+	if err != nil {
+		return Outcome{Err: err}
+	}
+	if u.Hostname() == "" {
+		return Outcome{Err: fmt.Errorf("missing host in %s", target)}
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	// The chain is verified by hand below, an expired or untrusted
+	// certificate must not hide the handshake timing
+	config := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: true,
+	}
+	conn, err := t.Resolver.dialContext(ctx, "tcp", address)
+	if err != nil {
+		if err := resolveError(ctx, err); err != nil {
+			return Outcome{Err: err}
+		}
+		return Outcome{Status: StatusUnreachable}
+	}
+	defer conn.Close()
+
+	tlsConn := tls.Client(conn, config)
+	start := time.Now()
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			return Outcome{Status: StatusUnreachable}
+		}
+		return Outcome{Err: fmt.Errorf("handshake failed: %w", err)}
+	}
+	rtt := time.Since(start)
+
+	state := tlsConn.ConnectionState()
+	leaf := state.PeerCertificates[0]
+	info := &TLSInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		NotAfter:    leaf.NotAfter,
+	}
+
+	return Outcome{
+		Status:  StatusAlive,
+		RTT:     rtt,
+		Reply:   fmt.Sprintf("%s, %s, expires %s", info.Version, info.CipherSuite, info.NotAfter.Format("2006-01-02")),
+		TLS:     info,
+		Warning: t.checkCertificate(state, u.Hostname()),
+	}
+}
+
+// checkCertificate returns a warning for an untrusted or (soon to be) expired
+// leaf certificate, or an empty string when the certificate is fine
+func (t *TLSProbe) checkCertificate(state tls.ConnectionState, host string) string {
+	leaf := state.PeerCertificates[0]
+	now := time.Now()
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         t.RootCAs,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+
+	days := int(leaf.NotAfter.Sub(now).Hours() / 24)
+	switch {
+	case now.After(leaf.NotAfter):
+		return fmt.Sprintf("certificate expired on %s", leaf.NotAfter.Format("2006-01-02"))
+	case err != nil:
+		return fmt.Sprintf("certificate not trusted: %v", err)
+	case days < t.WarnDays:
+		return fmt.Sprintf("certificate expires in %d days on %s", days, leaf.NotAfter.Format("2006-01-02"))
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package ping
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTLSProbe(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	// The test certificate is issued for example.com and 127.0.0.1
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	target := "tls://" + strings.TrimPrefix(server.URL, "https://")
+
+	tests := []struct {
+		name     string
+		roots    *x509.CertPool
+		warnDays int
+		warning  string
+	}{
+		{
+			name:     "Valid certificate",
+			roots:    roots,
+			warnDays: DefaultCertWarnDays,
+			warning:  "",
+		},
+		{
+			name:     "Expires within warning window",
+			roots:    roots,
+			warnDays: 365 * 1000,
+			warning:  "certificate expires in",
+		},
+		{
+			name:     "Untrusted certificate",
+			roots:    x509.NewCertPool(),
+			warnDays: DefaultCertWarnDays,
+			warning:  "certificate not trusted",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			probe := &TLSProbe{WarnDays: test.warnDays, RootCAs: test.roots}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			outcome := probe.Probe(ctx, target, 1)
+			if outcome.Err != nil {
+				t.Fatalf("Probe() error = %v", outcome.Err)
+			}
+			if outcome.Status != StatusAlive {
+				t.Fatalf("Probe() status = %v, want %v", outcome.Status, StatusAlive)
+			}
+			if outcome.TLS == nil || outcome.TLS.Version == "" || outcome.TLS.CipherSuite == "" {
+				t.Errorf("Probe() TLS = %+v, want negotiated version and cipher", outcome.TLS)
+			}
+			if !outcome.TLS.NotAfter.Equal(server.Certificate().NotAfter) {
+				t.Errorf("Probe() NotAfter = %v, want %v", outcome.TLS.NotAfter, server.Certificate().NotAfter)
+			}
+			if !strings.HasPrefix(outcome.Warning, test.warning) || (test.warning == "") != (outcome.Warning == "") {
+				t.Errorf("Probe() warning = %q, want prefix %q", outcome.Warning, test.warning)
+			}
+		})
+	}
+}
+
+func TestTLSProbeUnreachable(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	target := "tls://" + strings.TrimPrefix(server.URL, "https://")
+	server.Close()
+
+	probe := &TLSProbe{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	outcome := probe.Probe(ctx, target, 1)
+	if outcome.Status != StatusUnreachable {
+		t.Errorf("Probe() status = %v, want %v", outcome.Status, StatusUnreachable)
+	}
+}
+
+func TestTLSProbeUnresolved(t *testing.T) {
+	probe := &TLSProbe{}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	outcome := probe.Probe(ctx, "tls://missing.invalid", 1)
+	if !errors.Is(outcome.Err, ErrUnresolved) {
+		t.Errorf("Probe() = %+v, want an unresolved name", outcome)
+	}
+}
@@ -0,0 +1,159 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/windows-fping/goping/target"
+)
+
+// DefaultUDPPort is the destination port used for UDP probes. It is the first
+// traceroute port, which is almost never bound on a real host.
+const DefaultUDPPort = 33434
+
+// defaultUDPPayload is sent when no payload is configured, since some stacks
+// silently drop empty datagrams
+var defaultUDPPayload = []byte("goping")
+
+func init() {
+	Register("udp", func() Prober { return &UDPProbe{} })
+}
+
+// UDPProbe sends a datagram to a target and treats either an application
+// reply or an ICMP port unreachable error as proof that the host is up.
+// Targets are plain hosts or udp://host:port to override the port.
+type UDPProbe struct {
+	Port     int
+	Payload  []byte
+	Resolver *Resolver
+	// Exclude lists addresses no datagram is sent to
+	Exclude *target.ExcludeList
+}
+
+// Prepare takes the port and payload from config
+func (u *UDPProbe) Prepare(config Config) error {
+	u.Port = config.UDPPort
+	u.Payload = config.UDPPayload
+	u.Resolver = config.Resolver
+	u.Exclude = config.Exclude
+	return nil
+}
+
+// Close does nothing, every probe uses its own socket
+func (u *UDPProbe) Close() error {
+	return nil
+}
+
+// udpAddress returns host:port for a UDP target
+func (u *UDPProbe) udpAddress(target string) (string, error) {
+	port := u.Port
+	if port == 0 {
+		port = DefaultUDPPort
+	}
+
+	host := trimScheme(target)
+	if strings.Contains(host, ":") {
+		h, p, err := net.SplitHostPort(host)
+		if err != nil {
+			return "", err
+		}
+		port, err = strconv.Atoi(p)
+		if err != nil {
+			return "", fmt.Errorf("invalid port in %s", target)
+		}
+		host = h
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// resolve turns host:port into a UDP address, using the resolver if one is set
+func (u *UDPProbe) resolve(ctx context.Context, address string) (*net.UDPAddr, error) {
+	if u.Resolver == nil {
+		return net.ResolveUDPAddr("udp4", address)
+	}
+	host, portText, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := u.Resolver.Lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return &net.UDPAddr{IP: ips[0], Port: port}, nil
+}
+
+// Probe sends a single datagram to target and waits for the answer
+func (u *UDPProbe) Probe(ctx context.Context, target string, seq int) Outcome {
+	payload := u.Payload
+	if len(payload) == 0 {
+		payload = defaultUDPPayload
+	}
+
+	address, err := u.udpAddress(target)
+	if err != nil {
+		return Outcome{Err: err}
+	}
+	udpAddr, err := u.resolve(ctx, address)
+	if err != nil {
+		// Running out of time while the name is resolved is a lost probe,
+		// not a name that does not resolve
+		if ctx.Err() != nil {
+			return Outcome{Status: StatusUnreachable}
+		}
+		return Outcome{Err: fmt.Errorf("%w: %w", ErrUnresolved, err)}
+	}
+	if u.Exclude.Contains(udpAddr.IP) {
+		return Outcome{Err: fmt.Errorf("%w %s", ErrExcluded, udpAddr.IP)}
+	}
+
+	conn, err := net.DialUDP("udp4", nil, udpAddr)
+	if err != nil {
+		return Outcome{Err: err}
+	}
+	defer conn.Close()
+
+	// Port unreachable errors are only reported on connected sockets, and
+	// some platforms need to be asked for them explicitly
+	if err := enablePortUnreachable(conn); err != nil {
+		return Outcome{Err: err}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return Outcome{Err: err}
+		}
+	}
+
+	start := time.Now()
+	if _, err := conn.Write(payload); err != nil {
+		if isPortUnreachable(err) {
+			return Outcome{Status: StatusAlive, RTT: time.Since(start), Reply: "port unreachable"}
+		}
+		return Outcome{Err: err}
+	}
+
+	buffer := make([]byte, 1500)
+	n, err := conn.Read(buffer)
+	rtt := time.Since(start)
+	if err == nil {
+		return Outcome{Status: StatusAlive, RTT: rtt, Reply: strconv.Itoa(n) + " bytes"}
+	}
+	if isPortUnreachable(err) {
+		return Outcome{Status: StatusAlive, RTT: rtt, Reply: "port unreachable"}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return Outcome{Status: StatusUnreachable}
+	}
+	return Outcome{Err: err}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package ping
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// enablePortUnreachable is a no-op, connected sockets report the error already
+func enablePortUnreachable(conn *net.UDPConn) error {
+	return nil
+}
+
+// isPortUnreachable reports whether err was caused by an ICMP port unreachable
+func isPortUnreachable(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
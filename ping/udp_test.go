@@ -0,0 +1,134 @@
+package ping
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPProbeApplicationReply(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() error = %v", err)
+	}
+	defer server.Close()
+
+	// Echo every datagram back to its sender
+	go func() {
+		buffer := make([]byte, 1500)
+		for {
+			n, addr, err := server.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			server.WriteToUDP(buffer[:n], addr)
+		}
+	}()
+
+	probe := &UDPProbe{
+		Port:    server.LocalAddr().(*net.UDPAddr).Port,
+		Payload: []byte("hello"),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	outcome := probe.Probe(ctx, "127.0.0.1", 1)
+	if outcome.Err != nil {
+		t.Fatalf("Probe() error = %v", outcome.Err)
+	}
+	if outcome.Status != StatusAlive {
+		t.Errorf("Probe() status = %v, want %v", outcome.Status, StatusAlive)
+	}
+	if outcome.Reply != "5 bytes" {
+		t.Errorf("Probe() reply = %q, want %q", outcome.Reply, "5 bytes")
+	}
+}
+
+func TestUDPProbePortUnreachable(t *testing.T) {
+	// Grab a free port and release it so nothing is listening there
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() error = %v", err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+
+	probe := &UDPProbe{Port: port}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	outcome := probe.Probe(ctx, "127.0.0.1", 1)
+	if outcome.Err != nil {
+		t.Fatalf("Probe() error = %v", outcome.Err)
+	}
+	if outcome.Status != StatusAlive || outcome.Reply != "port unreachable" {
+		t.Errorf("Probe() = %+v, want alive via port unreachable", outcome)
+	}
+}
+
+func TestUDPProbeTimeout(t *testing.T) {
+	// A bound socket that never answers swallows the probe
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() error = %v", err)
+	}
+	defer server.Close()
+
+	probe := &UDPProbe{Port: server.LocalAddr().(*net.UDPAddr).Port}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	outcome := probe.Probe(ctx, "127.0.0.1", 1)
+	if outcome.Err != nil {
+		t.Fatalf("Probe() error = %v", outcome.Err)
+	}
+	if outcome.Status != StatusUnreachable {
+		t.Errorf("Probe() status = %v, want %v", outcome.Status, StatusUnreachable)
+	}
+}
+
+func TestUDPProbeResolveTimeout(t *testing.T) {
+	// A DNS server that never answers keeps the name from resolving before
+	// the probe times out
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() error = %v", err)
+	}
+	defer server.Close()
+	resolver := NewResolver()
+	resolver.Server = server.LocalAddr().String()
+
+	probe := &UDPProbe{Port: DefaultUDPPort, Resolver: resolver}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	outcome := probe.Probe(ctx, "slow.example", 1)
+	if outcome.Err != nil || outcome.Status != StatusUnreachable {
+		t.Errorf("Probe() = %+v, want a lost probe rather than an unresolved name", outcome)
+	}
+}
+
+func TestUDPProbeTargetPort(t *testing.T) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() error = %v", err)
+	}
+	defer server.Close()
+
+	go func() {
+		buffer := make([]byte, 1500)
+		n, addr, err := server.ReadFromUDP(buffer)
+		if err == nil {
+			server.WriteToUDP(buffer[:n], addr)
+		}
+	}()
+
+	// The port in a udp:// target wins over the configured one
+	probe := &UDPProbe{Port: DefaultUDPPort}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	outcome := probe.Probe(ctx, "udp://"+server.LocalAddr().String(), 1)
+	if outcome.Err != nil {
+		t.Fatalf("Probe() error = %v", outcome.Err)
+	}
+	if outcome.Status != StatusAlive || outcome.Reply != "6 bytes" {
+		t.Errorf("Probe() = %+v, want alive with the default payload echoed", outcome)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package ping
+
+import (
+	"errors"
+	"net"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// enablePortUnreachable turns SIO_UDP_CONNRESET back on. The Go runtime
+// disables it for every UDP socket, which hides ICMP port unreachable errors.
+func enablePortUnreachable(conn *net.UDPConn) error {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var ioctlErr error
+	err = rawConn.Control(func(fd uintptr) {
+		flag := uint32(1)
+		var ret uint32
+		ioctlErr = windows.WSAIoctl(windows.Handle(fd), windows.SIO_UDP_CONNRESET,
+			(*byte)(unsafe.Pointer(&flag)), uint32(unsafe.Sizeof(flag)), nil, 0, &ret, nil, 0)
+	})
+	if err != nil {
+		return err
+	}
+	return ioctlErr
+}
+
+// isPortUnreachable reports whether err was caused by an ICMP port unreachable
+func isPortUnreachable(err error) bool {
+	return errors.Is(err, windows.WSAECONNRESET)
+}
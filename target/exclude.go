@@ -0,0 +1,122 @@
+package target
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// addrRange is an inclusive range of IPv4 addresses
+type addrRange struct {
+	start uint32
+	end   uint32
+}
+
+// ExcludeList is a set of IPv4 addresses that must never be probed, built
+// from single addresses, ranges and networks in CIDR notation
+type ExcludeList struct {
+	ranges []addrRange
+}
+
+// NewExcludeList creates an exclude list from IPs, ranges and CIDRs
+func NewExcludeList(entries []string) (*ExcludeList, error) {
+	list := &ExcludeList{}
+	for _, entry := range entries {
+		if err := list.Add(entry); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// AddFile adds every entry of a file, one per line, to the list
+func (l *ExcludeList) AddFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entries, err := readLines(file)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := l.Add(entry); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	return nil
+}
+
+// Add adds a single IP, range or CIDR to the list. Unlike with targets, the
+// network and broadcast addresses of a CIDR are excluded too.
+func (l *ExcludeList) Add(entry string) error {
+	entry = strings.TrimSpace(entry)
+
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid exclude %q: %w", entry, err)
+		}
+		ip := ipNet.IP.To4()
+		if ip == nil {
+			return fmt.Errorf("invalid exclude %q: only IPv4 is supported", entry)
+		}
+		start := ipToUint32(ip)
+		ones, _ := ipNet.Mask.Size()
+		end := start | uint32(0xffffffff>>ones)
+		l.ranges = append(l.ranges, addrRange{start: start, end: end})
+		return nil
+	}
+
+	if startIP, endIP, ok := strings.Cut(entry, "-"); ok {
+		start := net.ParseIP(strings.TrimSpace(startIP)).To4()
+		end := net.ParseIP(strings.TrimSpace(endIP)).To4()
+		if start == nil || end == nil {
+			return fmt.Errorf("invalid exclude %q: expected an IPv4 range start-end", entry)
+		}
+		if !lessThanOrEqual(start, end) {
+			return fmt.Errorf("invalid exclude %q: start IP must be less than or equal to end IP", entry)
+		}
+		l.ranges = append(l.ranges, addrRange{start: ipToUint32(start), end: ipToUint32(end)})
+		return nil
+	}
+
+	ip := net.ParseIP(entry).To4()
+	if ip == nil {
+		return fmt.Errorf("invalid exclude %q: expected an IPv4 address, range or CIDR", entry)
+	}
+	l.ranges = append(l.ranges, addrRange{start: ipToUint32(ip), end: ipToUint32(ip)})
+	return nil
+}
+
+// Len returns the number of entries in the list
+func (l *ExcludeList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.ranges)
+}
+
+// Contains reports whether ip is excluded
+func (l *ExcludeList) Contains(ip net.IP) bool {
+	ip4 := ip.To4()
+	if l == nil || ip4 == nil {
+		return false
+	}
+	n := ipToUint32(ip4)
+	for _, r := range l.ranges {
+		if n >= r.start && n <= r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// ipToUint32 converts a 4 byte IPv4 address to a number
+func ipToUint32(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
@@ -0,0 +1,80 @@
+package target
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExcludeList(t *testing.T) {
+	list, err := NewExcludeList([]string{"10.0.0.5", "10.0.1.10-10.0.1.20", "10.0.2.0/30"})
+	if err != nil {
+		t.Fatalf("NewExcludeList() error = %v", err)
+	}
+
+	tests := []struct {
+		ip       string
+		excluded bool
+	}{
+		{ip: "10.0.0.5", excluded: true},
+		{ip: "10.0.0.6", excluded: false},
+		{ip: "10.0.1.9", excluded: false},
+		{ip: "10.0.1.10", excluded: true},
+		{ip: "10.0.1.20", excluded: true},
+		{ip: "10.0.1.21", excluded: false},
+		{ip: "10.0.2.0", excluded: true},
+		{ip: "10.0.2.3", excluded: true},
+		{ip: "10.0.2.4", excluded: false},
+	}
+
+	for _, test := range tests {
+		if got := list.Contains(net.ParseIP(test.ip)); got != test.excluded {
+			t.Errorf("Contains(%s) = %v, want %v", test.ip, got, test.excluded)
+		}
+	}
+}
+
+func TestExcludeListInvalid(t *testing.T) {
+	for _, entry := range []string{"printer.local", "10.0.0.0/33", "10.0.0.9-10.0.0.1", "10.0.0.1-bogus"} {
+		if _, err := NewExcludeList([]string{entry}); err == nil {
+			t.Errorf("NewExcludeList(%q) error = nil, want error", entry)
+		}
+	}
+}
+
+func TestExcludeListSet(t *testing.T) {
+	specs, err := ParseAll([]string{"192.168.1.0/29", "192.168.1.3", "udp://192.168.1.4:53", "example.com"})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	set, err := NewSet(specs)
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "exclude.txt")
+	if err := os.WriteFile(filename, []byte("# fragile PLCs\n192.168.1.3-192.168.1.4\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	list, err := NewExcludeList([]string{"192.168.1.6"})
+	if err != nil {
+		t.Fatalf("NewExcludeList() error = %v", err)
+	}
+	if err := list.AddFile(filename); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+
+	set.Exclude(list)
+
+	var targets []string
+	for spec := range set.All() {
+		targets = append(targets, spec.Target)
+	}
+	expected := []string{"192.168.1.1", "192.168.1.2", "192.168.1.5", "example.com"}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Errorf("All() = %v, want %v", targets, expected)
+	}
+}
@@ -0,0 +1,417 @@
+package target
+
+import (
+	"encoding/binary"
+	"fmt"
+	"iter"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Iterator yields target specs one at a time. Iterators returned by this
+// package can be ranged over more than once and yield the same specs each time.
+type Iterator iter.Seq[Spec]
+
+// addrSource is an indexable list of IPv4 addresses, so that huge networks
+// can be walked without ever being materialized
+type addrSource interface {
+	// Len returns the number of addresses
+	Len() uint64
+	// At returns the i-th address
+	At(i uint64) uint32
+	// Contains reports whether addr is one of the addresses
+	Contains(addr uint32) bool
+	// Width returns the length of the longest address in dotted notation
+	Width() int
+}
+
+// cidrSource holds the usable addresses of a network. Like GenerateFromCIDR
+// always did, the network and broadcast addresses are skipped unless it is a /32.
+type cidrSource struct {
+	first uint32
+	n     uint64
+}
+
+func newCIDRSource(cidr string) (*cidrSource, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	ip := ipNet.IP.To4()
+	if ip == nil {
+		return nil, fmt.Errorf("only IPv4 CIDR notation is supported")
+	}
+
+	ones, _ := ipNet.Mask.Size()
+	first := ipToUint32(ip)
+	n := uint64(1) << (32 - ones)
+	if ones < 32 {
+		first++
+		n -= 2
+	}
+	return &cidrSource{first: first, n: n}, nil
+}
+
+func (s *cidrSource) Len() uint64 { return s.n }
+
+func (s *cidrSource) At(i uint64) uint32 { return s.first + uint32(i) }
+
+func (s *cidrSource) Contains(addr uint32) bool {
+	return s.n > 0 && addr >= s.first && uint64(addr-s.first) < s.n
+}
+
+func (s *cidrSource) Width() int {
+	if s.n == 0 {
+		return 0
+	}
+	return widestBetween(s.first, s.first+uint32(s.n-1))
+}
+
+// rangeSource holds every address from start to end inclusive
+type rangeSource struct {
+	start uint32
+	end   uint32
+}
+
+func newRangeSource(startIP, endIP string) (*rangeSource, error) {
+	start := net.ParseIP(startIP)
+	if start == nil {
+		return nil, fmt.Errorf("invalid start IP: %s", startIP)
+	}
+
+	end := net.ParseIP(endIP)
+	if end == nil {
+		return nil, fmt.Errorf("invalid end IP: %s", endIP)
+	}
+
+	// We only support IPv4 for now
+	start = start.To4()
+	end = end.To4()
+
+	if start == nil || end == nil {
+		return nil, fmt.Errorf("only IPv4 addresses are supported")
+	}
+
+	// Compare start and end IPs
+	if !lessThanOrEqual(start, end) {
+		return nil, fmt.Errorf("start IP must be less than or equal to end IP")
+	}
+
+	return &rangeSource{start: ipToUint32(start), end: ipToUint32(end)}, nil
+}
+
+func (s *rangeSource) Len() uint64 { return uint64(s.end-s.start) + 1 }
+
+func (s *rangeSource) At(i uint64) uint32 { return s.start + uint32(i) }
+
+func (s *rangeSource) Contains(addr uint32) bool { return addr >= s.start && addr <= s.end }
+
+func (s *rangeSource) Width() int { return widestBetween(s.start, s.end) }
+
+// widestBetween returns the length of the longest address from lo to hi. Any
+// address below hi is at most as long as the one that keeps the octets of hi
+// above the first octet where they differ, has that octet one lower and 255
+// in the octets below it, so only those candidates need to be measured.
+func widestBetween(lo, hi uint32) int {
+	width := addrWidth(hi)
+	for octet := 0; octet < 4; octet++ {
+		shift := 24 - 8*octet
+		if byte(hi>>shift) == 0 {
+			continue
+		}
+		octetMask := uint32(0xff) << shift
+		below := uint32(1)<<shift - 1
+		candidate := hi&^(octetMask|below) | (hi&octetMask - 1<<shift) | below
+		if candidate >= lo {
+			width = max(width, addrWidth(candidate))
+		}
+	}
+	return width
+}
+
+// addrWidth returns the length of addr in dotted notation
+func addrWidth(addr uint32) int {
+	return len(uint32ToAddr(addr).String())
+}
+
+// octetSource holds the addresses of an nmap-style octet pattern
+type octetSource struct {
+	octets [4][]int
+	member [4][256]bool
+}
+
+func newOctetSource(pattern string) (*octetSource, error) {
+	octets, err := parseOctets(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s := &octetSource{octets: octets}
+	for i, values := range octets {
+		for _, v := range values {
+			s.member[i][v] = true
+		}
+	}
+	return s, nil
+}
+
+func (s *octetSource) Len() uint64 {
+	n := uint64(1)
+	for _, values := range s.octets {
+		n *= uint64(len(values))
+	}
+	return n
+}
+
+// At treats i as a mixed-radix number with one digit per octet, so the
+// addresses come out in the same order as nested loops would produce them
+func (s *octetSource) At(i uint64) uint32 {
+	var addr [4]byte
+	for octet := 3; octet >= 0; octet-- {
+		values := s.octets[octet]
+		addr[octet] = byte(values[i%uint64(len(values))])
+		i /= uint64(len(values))
+	}
+	return binary.BigEndian.Uint32(addr[:])
+}
+
+func (s *octetSource) Width() int {
+	width := 3
+	for _, values := range s.octets {
+		width += len(strconv.Itoa(slices.Max(values)))
+	}
+	return width
+}
+
+func (s *octetSource) Contains(addr uint32) bool {
+	for i := 0; i < 4; i++ {
+		if !s.member[i][byte(addr>>(24-8*i))] {
+			return false
+		}
+	}
+	return true
+}
+
+// addrs turns an addrSource into an address iterator
+func addrs(source addrSource) iter.Seq[netip.Addr] {
+	return func(yield func(netip.Addr) bool) {
+		for i := uint64(0); i < source.Len(); i++ {
+			if !yield(uint32ToAddr(source.At(i))) {
+				return
+			}
+		}
+	}
+}
+
+// CIDRAddrs lazily yields the usable addresses of a network in CIDR notation
+func CIDRAddrs(cidr string) (iter.Seq[netip.Addr], error) {
+	source, err := newCIDRSource(cidr)
+	if err != nil {
+		return nil, err
+	}
+	return addrs(source), nil
+}
+
+// RangeAddrs lazily yields every address from startIP to endIP inclusive
+func RangeAddrs(startIP, endIP string) (iter.Seq[netip.Addr], error) {
+	source, err := newRangeSource(startIP, endIP)
+	if err != nil {
+		return nil, err
+	}
+	return addrs(source), nil
+}
+
+// OctetAddrs lazily yields the addresses of an nmap-style octet pattern
+func OctetAddrs(pattern string) (iter.Seq[netip.Addr], error) {
+	source, err := newOctetSource(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return addrs(source), nil
+}
+
+// setEntry is one spec of a Set, with the addresses it expands to if any
+type setEntry struct {
+	spec  Spec
+	addrs addrSource
+}
+
+// Len returns the number of targets the entry expands to
+func (e setEntry) Len() uint64 {
+	if e.addrs == nil {
+		return 1
+	}
+	return e.addrs.Len()
+}
+
+// Set is an ordered collection of target specs. CIDRs, ranges and octet
+// patterns are expanded only while the set is iterated, so memory use does
+// not depend on how many addresses they cover.
+type Set struct {
+	entries []setEntry
+	// first maps each host target to the index of the entry it first appears in
+	first map[string]int
+	// sources holds the indices of the entries that expand to addresses, the
+	// only ones an address has to be checked against besides first
+	sources []int
+	exclude *ExcludeList
+	// offsets holds the position of each entry's first target in the set's
+	// virtual index space
+	offsets []uint64
+	n       uint64
+	order   Order
+	seed    uint64
+}
+
+// NewSet creates a set from parsed specs
+func NewSet(specs []Spec) (*Set, error) {
+	set := &Set{first: make(map[string]int)}
+	for _, spec := range specs {
+		entry := setEntry{spec: spec}
+
+		var err error
+		switch spec.Kind {
+		case KindCIDR:
+			entry.addrs, err = newCIDRSource(spec.Target)
+		case KindRange:
+			start, end, _ := strings.Cut(spec.Target, "-")
+			entry.addrs, err = newRangeSource(strings.TrimSpace(start), strings.TrimSpace(end))
+		case KindOctets:
+			entry.addrs, err = newOctetSource(spec.Target)
+		default:
+			if _, seen := set.first[spec.Target]; !seen {
+				set.first[spec.Target] = len(set.entries)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error generating targets from %s: %w", spec.Target, err)
+		}
+
+		if entry.addrs != nil {
+			set.sources = append(set.sources, len(set.entries))
+		}
+		set.offsets = append(set.offsets, set.n)
+		set.entries = append(set.entries, entry)
+		set.n += entry.Len()
+	}
+	return set, nil
+}
+
+// Exclude makes the set skip every address in list
+func (s *Set) Exclude(list *ExcludeList) {
+	s.exclude = list
+}
+
+// Width returns the length of the longest target of the set, counting the
+// addresses of networks, ranges and octet patterns without expanding them
+func (s *Set) Width() int {
+	width := 0
+	for _, entry := range s.entries {
+		if entry.addrs == nil {
+			width = max(width, len(entry.spec.Target))
+		} else {
+			width = max(width, entry.addrs.Width())
+		}
+	}
+	return width
+}
+
+// Len returns the number of targets before duplicates and exclusions are dropped
+func (s *Set) Len() uint64 {
+	return s.n
+}
+
+// Contains reports whether target is one of the set's targets, before
+// exclusions are applied
+func (s *Set) Contains(target string) bool {
+	if _, ok := s.first[target]; ok {
+		return true
+	}
+	ip, err := netip.ParseAddr(target)
+	if err != nil || !ip.Is4() {
+		return false
+	}
+	addr := binary.BigEndian.Uint32(ip.AsSlice())
+	for _, j := range s.sources {
+		if s.entries[j].addrs.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// All yields every target, by default in the order the specs were given
+func (s *Set) All() Iterator {
+	return func(yield func(Spec) bool) {
+		for i := range s.indices {
+			k, j := s.locate(i)
+			if spec, ok := s.at(k, j); ok && !yield(spec) {
+				return
+			}
+		}
+	}
+}
+
+// at returns the i-th target of entry k. It reports false if the target is
+// excluded or already covered by an earlier entry.
+func (s *Set) at(k int, i uint64) (Spec, bool) {
+	entry := s.entries[k]
+	spec := entry.spec
+
+	var addr uint32
+	isAddr := false
+	if entry.addrs != nil {
+		addr = entry.addrs.At(i)
+		ip := uint32ToAddr(addr).String()
+		spec.Target = ip
+		spec.Host = ip
+		spec.Kind = KindHost
+		isAddr = true
+	} else if ip, err := netip.ParseAddr(spec.Target); err == nil && ip.Is4() {
+		addr = binary.BigEndian.Uint32(ip.AsSlice())
+		isAddr = true
+	}
+
+	// The first occurrence of a duplicate wins
+	if first, seen := s.first[spec.Target]; seen && first < k {
+		return Spec{}, false
+	}
+	if entry.addrs == nil && s.first[spec.Target] != k {
+		return Spec{}, false
+	}
+	if isAddr {
+		for _, j := range s.sources {
+			if j >= k {
+				break
+			}
+			if s.entries[j].addrs.Contains(addr) {
+				return Spec{}, false
+			}
+		}
+	}
+
+	if s.exclude.Len() > 0 {
+		if ip := net.ParseIP(spec.Host); ip != nil && s.exclude.Contains(ip) {
+			return Spec{}, false
+		}
+	}
+	return spec, true
+}
+
+// Collect returns every spec of it as a slice
+func Collect(it Iterator) []Spec {
+	var specs []Spec
+	for spec := range it {
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// uint32ToAddr converts a number to an IPv4 address
+func uint32ToAddr(n uint32) netip.Addr {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], n)
+	return netip.AddrFrom4(b)
+}
@@ -0,0 +1,198 @@
+package target
+
+import (
+	"fmt"
+	"iter"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAddrIterators(t *testing.T) {
+	tests := []struct {
+		name     string
+		generate func() ([]string, error)
+		lazy     func() ([]string, error)
+	}{
+		{
+			name:     "CIDR",
+			generate: func() ([]string, error) { return GenerateFromCIDR("192.168.1.0/29") },
+			lazy:     func() ([]string, error) { return collectAddrs(CIDRAddrs("192.168.1.0/29")) },
+		},
+		{
+			name:     "Single address CIDR",
+			generate: func() ([]string, error) { return GenerateFromCIDR("192.168.1.7/32") },
+			lazy:     func() ([]string, error) { return collectAddrs(CIDRAddrs("192.168.1.7/32")) },
+		},
+		{
+			name:     "Range across octets",
+			generate: func() ([]string, error) { return GenerateFromRange("10.0.0.254", "10.0.1.2") },
+			lazy:     func() ([]string, error) { return collectAddrs(RangeAddrs("10.0.0.254", "10.0.1.2")) },
+		},
+		{
+			name:     "Octets",
+			generate: func() ([]string, error) { return GenerateFromOctets("10.0-1.5,3.1-2") },
+			lazy:     func() ([]string, error) { return collectAddrs(OctetAddrs("10.0-1.5,3.1-2")) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expected, err := test.generate()
+			if err != nil {
+				t.Fatalf("generate error = %v", err)
+			}
+			got, err := test.lazy()
+			if err != nil {
+				t.Fatalf("iterator error = %v", err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("iterator = %v, want %v", got, expected)
+			}
+		})
+	}
+}
+
+func TestSetIsLazy(t *testing.T) {
+	specs, err := ParseAll([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	set, err := NewSet(specs)
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+	if set.Len() != 1<<24-2 {
+		t.Errorf("Len() = %d, want %d", set.Len(), 1<<24-2)
+	}
+
+	var targets []string
+	for spec := range set.All() {
+		targets = append(targets, spec.Target)
+		if len(targets) == 3 {
+			break
+		}
+	}
+	expected := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Errorf("All() = %v, want %v", targets, expected)
+	}
+}
+
+func TestSetManyLiterals(t *testing.T) {
+	// Literal addresses are deduplicated through a map, so a long target
+	// file iterates in linear time
+	var lines []string
+	for i := 0; i < 60000; i++ {
+		lines = append(lines, fmt.Sprintf("10.%d.%d.%d", i>>16, (i>>8)&0xff, i&0xff))
+	}
+	lines = append(lines, "10.0.0.5", "10.0.1.0/30")
+	specs, err := ParseAll(lines)
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	set, err := NewSet(specs)
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+
+	start := time.Now()
+	targets := Collect(set.All())
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("All() took %v for 60000 addresses", elapsed)
+	}
+	if len(targets) != 60000 {
+		t.Errorf("All() returned %d targets, want 60000 without duplicates", len(targets))
+	}
+}
+
+func TestSetExclude(t *testing.T) {
+	specs, err := ParseAll([]string{"192.168.1.0/29", "192.168.1.9", "example.com"})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	set, err := NewSet(specs)
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+	list, err := NewExcludeList([]string{"192.168.1.2-192.168.1.5", "192.168.1.9"})
+	if err != nil {
+		t.Fatalf("NewExcludeList() error = %v", err)
+	}
+	set.Exclude(list)
+
+	// Iterating twice yields the same targets
+	for range 2 {
+		var targets []string
+		for spec := range set.All() {
+			targets = append(targets, spec.Target)
+		}
+		expected := []string{"192.168.1.1", "192.168.1.6", "example.com"}
+		if !reflect.DeepEqual(targets, expected) {
+			t.Errorf("All() = %v, want %v", targets, expected)
+		}
+	}
+}
+
+// collectAddrs turns the addresses of an iterator into strings
+func collectAddrs(addrs iter.Seq[netip.Addr], err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	var ips []string
+	for addr := range addrs {
+		ips = append(ips, addr.String())
+	}
+	return ips, nil
+}
+
+func TestSetWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		specs    []string
+		expected int
+	}{
+		{name: "Hosts", specs: []string{"10.0.0.1", "10.0.0.100", "db"}, expected: 10},
+		{name: "URL", specs: []string{"10.0.0.1", "https://example.com/healthz"}, expected: 27},
+		{name: "Network", specs: []string{"10.0.0.0/30"}, expected: 8},
+		{name: "Range ending short", specs: []string{"10.0.0.100-10.0.1.5"}, expected: 10},
+		{name: "Range across octets", specs: []string{"9.255.255.250-10.0.0.1"}, expected: 13},
+		{name: "Octets", specs: []string{"192.168.1,20.1-9"}, expected: 12},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			specs, err := ParseAll(test.specs)
+			if err != nil {
+				t.Fatalf("ParseAll() error = %v", err)
+			}
+			set, err := NewSet(specs)
+			if err != nil {
+				t.Fatalf("NewSet() error = %v", err)
+			}
+			if got := set.Width(); got != test.expected {
+				t.Errorf("Width() = %d, want %d", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestWidestBetween(t *testing.T) {
+	// Compare against measuring every address of small ranges
+	bounds := []uint32{0x0a000000, 0x0a000009, 0x0a00000a, 0x0a000063, 0x0a000064, 0x0a0000ff, 0x0a000100, 0x0a000105, 0x0a0003e7, 0x09ffffff}
+	for _, lo := range bounds {
+		for _, hi := range bounds {
+			if lo > hi {
+				continue
+			}
+			widest := 0
+			for addr := lo; addr <= hi; addr++ {
+				widest = max(widest, addrWidth(addr))
+			}
+			if got := widestBetween(lo, hi); got != widest {
+				t.Errorf("widestBetween(%v, %v) = %d, want %d", uint32ToAddr(lo), uint32ToAddr(hi), got, widest)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package target
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// isOctetPattern reports whether s looks like nmap-style octet notation, e.g.
+// 10.0-3.1-254.1, 192.168.1.1,5,10-20 or 10.*.0.1
+func isOctetPattern(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		return false
+	}
+	for _, part := range parts {
+		if part == "" || strings.Trim(part, "0123456789,-*") != "" {
+			return false
+		}
+	}
+	return strings.ContainsAny(s, ",-*")
+}
+
+// GenerateFromOctets generates a list of IP addresses from nmap-style octet
+// notation. Each octet is a number, a range a-b, a comma separated list of
+// both, or * for 0-255.
+func GenerateFromOctets(pattern string) ([]string, error) {
+	octets, err := parseOctets(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []string
+	for _, a := range octets[0] {
+		for _, b := range octets[1] {
+			for _, c := range octets[2] {
+				for _, d := range octets[3] {
+					ips = append(ips, fmt.Sprintf("%d.%d.%d.%d", a, b, c, d))
+				}
+			}
+		}
+	}
+	return ips, nil
+}
+
+// parseOctets returns the sorted values of every octet in pattern
+func parseOctets(pattern string) ([4][]int, error) {
+	var octets [4][]int
+
+	parts := strings.Split(pattern, ".")
+	if len(parts) != 4 {
+		return octets, fmt.Errorf("invalid octet pattern %s: expected 4 octets, got %d", pattern, len(parts))
+	}
+
+	for i, part := range parts {
+		values, err := parseOctet(part)
+		if err != nil {
+			return octets, fmt.Errorf("invalid octet %d %q in %s: %w", i+1, part, pattern, err)
+		}
+		octets[i] = values
+	}
+	return octets, nil
+}
+
+// parseOctet expands a single octet such as 1,5,10-20 into its sorted values
+func parseOctet(octet string) ([]int, error) {
+	if octet == "" {
+		return nil, fmt.Errorf("empty octet")
+	}
+
+	seen := make(map[int]bool)
+	for _, item := range strings.Split(octet, ",") {
+		low, high, err := parseOctetItem(item)
+		if err != nil {
+			return nil, err
+		}
+		for v := low; v <= high; v++ {
+			seen[v] = true
+		}
+	}
+
+	values := make([]int, 0, len(seen))
+	for v := range seen {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	return values, nil
+}
+
+// parseOctetItem parses *, n, a-b or the open ranges -b and a-
+func parseOctetItem(item string) (int, int, error) {
+	if item == "*" {
+		return 0, 255, nil
+	}
+	if item == "" {
+		return 0, 0, fmt.Errorf("empty list item")
+	}
+
+	lowText, highText, isRange := strings.Cut(item, "-")
+	if !isRange {
+		v, err := parseOctetValue(item)
+		return v, v, err
+	}
+
+	low, high := 0, 255
+	var err error
+	if lowText != "" {
+		if low, err = parseOctetValue(lowText); err != nil {
+			return 0, 0, err
+		}
+	}
+	if highText != "" {
+		if high, err = parseOctetValue(highText); err != nil {
+			return 0, 0, err
+		}
+	}
+	if low > high {
+		return 0, 0, fmt.Errorf("range %s runs backwards", item)
+	}
+	return low, high, nil
+}
+
+// parseOctetValue parses a number between 0 and 255
+func parseOctetValue(s string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	if v < 0 || v > 255 {
+		return 0, fmt.Errorf("%d is out of range 0-255", v)
+	}
+	return v, nil
+}
@@ -0,0 +1,117 @@
+package target
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateFromOctets(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		expected []string
+		count    int
+		err      string
+	}{
+		{
+			name:     "List and range in last octet",
+			pattern:  "192.168.1.1,5,10-12",
+			expected: []string{"192.168.1.1", "192.168.1.5", "192.168.1.10", "192.168.1.11", "192.168.1.12"},
+		},
+		{
+			name:     "Ranges in several octets",
+			pattern:  "10.0-1.1-2.1",
+			expected: []string{"10.0.1.1", "10.0.2.1", "10.1.1.1", "10.1.2.1"},
+		},
+		{
+			name:    "Wildcard",
+			pattern: "10.*.0.1",
+			count:   256,
+		},
+		{
+			name:    "Open ranges",
+			pattern: "10.0.0.-3,250-",
+			count:   10,
+		},
+		{
+			name:     "Overlapping items are deduplicated",
+			pattern:  "10.0.0.1-3,2",
+			expected: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+		{
+			name:    "Octet out of range",
+			pattern: "10.0.0.1-256",
+			err:     `invalid octet 4 "1-256" in 10.0.0.1-256: 256 is out of range 0-255`,
+		},
+		{
+			name:    "Backwards range",
+			pattern: "10.0.5-1.1",
+			err:     `invalid octet 3 "5-1" in 10.0.5-1.1: range 5-1 runs backwards`,
+		},
+		{
+			name:    "Empty list item",
+			pattern: "10.0.0.1,,2",
+			err:     `invalid octet 4 "1,,2" in 10.0.0.1,,2: empty list item`,
+		},
+		{
+			name:    "Too few octets",
+			pattern: "10.0.1-5",
+			err:     "invalid octet pattern 10.0.1-5: expected 4 octets, got 3",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := GenerateFromOctets(test.pattern)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("GenerateFromOctets() error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateFromOctets() error = %v", err)
+			}
+			if test.expected != nil && !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("GenerateFromOctets() = %v, want %v", got, test.expected)
+			}
+			if test.count != 0 && len(got) != test.count {
+				t.Errorf("GenerateFromOctets() returned %d IPs, want %d", len(got), test.count)
+			}
+		})
+	}
+}
+
+func TestParseOctetPattern(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  Kind
+		err   string
+	}{
+		{input: "10.0-3.1-254.1", kind: KindOctets},
+		{input: "192.168.1.1,5,10-20", kind: KindOctets},
+		{input: "10.*.0.1", kind: KindOctets},
+		{input: "10.0.0.1-5", kind: KindOctets},
+		{input: "10.0.0.1-10.0.0.5", kind: KindRange},
+		{input: "core-rtr-1", kind: KindHost},
+		{input: "10.0.0.1-300", err: "out of range"},
+	}
+
+	for _, test := range tests {
+		spec, err := Parse(test.input)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Parse(%q) error = %v, want %q", test.input, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", test.input, err)
+			continue
+		}
+		if spec.Kind != test.kind {
+			t.Errorf("Parse(%q) kind = %v, want %v", test.input, spec.Kind, test.kind)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package target
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand/v2"
+	"sort"
+	"strings"
+)
+
+// Order is the order in which the targets of a Set are visited
+type Order int
+
+const (
+	// OrderSequential visits targets in the order they were given
+	OrderSequential Order = iota
+	// OrderRandom visits targets in a seeded pseudo-random permutation
+	OrderRandom
+	// OrderInterleave visits every 256th target first, so that consecutive
+	// probes to a network go to different /24 subnets
+	OrderInterleave
+)
+
+// interleaveStride is the distance between consecutive targets in OrderInterleave
+const interleaveStride = 256
+
+// ParseOrder parses sequential, random or interleave
+func ParseOrder(s string) (Order, error) {
+	switch strings.ToLower(s) {
+	case "sequential", "":
+		return OrderSequential, nil
+	case "random":
+		return OrderRandom, nil
+	case "interleave":
+		return OrderInterleave, nil
+	}
+	return 0, fmt.Errorf("invalid order %q: expected random, interleave or sequential", s)
+}
+
+// String returns the name of the order
+func (o Order) String() string {
+	switch o {
+	case OrderRandom:
+		return "random"
+	case OrderInterleave:
+		return "interleave"
+	}
+	return "sequential"
+}
+
+// SetOrder makes All visit the targets in order. The seed picks the
+// permutation used by OrderRandom, so the same seed gives the same order.
+func (s *Set) SetOrder(order Order, seed uint64) {
+	s.order = order
+	s.seed = seed
+}
+
+// indices yields every position of the set's virtual index space once, in
+// the set's order, without allocating anything proportional to its size
+func (s *Set) indices(yield func(uint64) bool) {
+	n := s.Len()
+	switch s.order {
+	case OrderRandom:
+		permutation(n, s.seed)(yield)
+	case OrderInterleave:
+		for offset := uint64(0); offset < interleaveStride && offset < n; offset++ {
+			for i := offset; i < n; i += interleaveStride {
+				if !yield(i) {
+					return
+				}
+			}
+		}
+	default:
+		for i := uint64(0); i < n; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// locate maps a position of the virtual index space to an entry and the
+// index within that entry
+func (s *Set) locate(i uint64) (int, uint64) {
+	k := sort.Search(len(s.offsets), func(k int) bool { return s.offsets[k] > i }) - 1
+	return k, i - s.offsets[k]
+}
+
+// permutation yields 0..n-1 in a pseudo-random order. It walks a full-period
+// linear congruential generator modulo the next power of two and skips the
+// values that are out of range, so it needs constant memory.
+func permutation(n, seed uint64) func(yield func(uint64) bool) {
+	return func(yield func(uint64) bool) {
+		if n == 0 {
+			return
+		}
+		m := uint64(1) << bits.Len64(n-1)
+		mask := m - 1
+
+		// Any a with a%4 == 1 and odd c give a full period modulo a power of two
+		rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+		a := (rng.Uint64()<<2 | 1) & mask
+		if m <= 4 {
+			a = 1
+		}
+		c := (rng.Uint64() | 1) & mask
+		x := rng.Uint64() & mask
+
+		for range m {
+			x = (a*x + c) & mask
+			if x < n && !yield(x) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package target
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Order
+		wantErr  bool
+	}{
+		{input: "sequential", expected: OrderSequential},
+		{input: "Random", expected: OrderRandom},
+		{input: "interleave", expected: OrderInterleave},
+		{input: "reverse", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, err := ParseOrder(test.input)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseOrder() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr && got != test.expected {
+				t.Errorf("ParseOrder() = %v, want %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestPermutation(t *testing.T) {
+	for _, n := range []uint64{1, 2, 3, 4, 5, 254, 1000} {
+		seen := make(map[uint64]bool)
+		for i := range permutation(n, 42) {
+			if i >= n || seen[i] {
+				t.Fatalf("permutation(%d) yielded %d twice or out of range", n, i)
+			}
+			seen[i] = true
+		}
+		if uint64(len(seen)) != n {
+			t.Errorf("permutation(%d) yielded %d values", n, len(seen))
+		}
+	}
+}
+
+func TestSetOrder(t *testing.T) {
+	sweep := func(order Order, seed uint64) []string {
+		specs, err := ParseAll([]string{"10.0.0.0/22", "example.com"})
+		if err != nil {
+			t.Fatalf("ParseAll() error = %v", err)
+		}
+		set, err := NewSet(specs)
+		if err != nil {
+			t.Fatalf("NewSet() error = %v", err)
+		}
+		set.SetOrder(order, seed)
+
+		var targets []string
+		for spec := range set.All() {
+			targets = append(targets, spec.Target)
+		}
+		return targets
+	}
+
+	sequential := sweep(OrderSequential, 0)
+	if len(sequential) != 1023 {
+		t.Fatalf("sequential sweep has %d targets, want 1023", len(sequential))
+	}
+
+	interleaved := sweep(OrderInterleave, 0)
+	expected := []string{"10.0.0.1", "10.0.1.1", "10.0.2.1", "10.0.3.1", "10.0.0.2"}
+	if !reflect.DeepEqual(interleaved[:5], expected) {
+		t.Errorf("interleave starts with %v, want %v", interleaved[:5], expected)
+	}
+
+	random := sweep(OrderRandom, 7)
+	if reflect.DeepEqual(random, sequential) {
+		t.Errorf("random order is sequential")
+	}
+	if !reflect.DeepEqual(random, sweep(OrderRandom, 7)) {
+		t.Errorf("random order differs for the same seed")
+	}
+	if reflect.DeepEqual(random, sweep(OrderRandom, 8)) {
+		t.Errorf("random order is the same for different seeds")
+	}
+
+	// Every order visits the same targets
+	for _, targets := range [][]string{interleaved, random} {
+		seen := make(map[string]bool)
+		for _, target := range targets {
+			seen[target] = true
+		}
+		if len(seen) != len(sequential) || len(targets) != len(sequential) {
+			t.Errorf("order visited %d targets, %d distinct, want %d", len(targets), len(seen), len(sequential))
+		}
+	}
+}
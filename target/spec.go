@@ -0,0 +1,211 @@
+package target
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind tells what a Spec refers to
+type Kind int
+
+const (
+	// KindHost is a single host name, address or URL
+	KindHost Kind = iota
+	// KindCIDR is a network in CIDR notation, e.g. 10.0.0.0/24
+	KindCIDR
+	// KindRange is an address range, e.g. 10.0.0.1-10.0.0.50
+	KindRange
+	// KindOctets is an nmap-style octet pattern, e.g. 10.0-3.1-254.1
+	KindOctets
+)
+
+// Spec is a single target as written on the command line, in a file or on
+// stdin, e.g. "tcp://host:443" or "host;count=10;timeout=200ms;label=core-rtr"
+type Spec struct {
+	// Raw is the text the spec was parsed from
+	Raw string
+	// Target is the target without options, e.g. "tcp://host:443"
+	Target string
+	Kind   Kind
+	// Scheme is the lower-cased URL scheme, empty for plain targets
+	Scheme string
+	// Host is the host name or address without scheme, port or path
+	Host string
+	// Port is zero unless the target names one
+	Port int
+	// Count and Timeout override the global settings when non-zero
+	Count   int
+	Timeout time.Duration
+	// Label is shown next to the target in the summary
+	Label string
+}
+
+// Parse parses a single target spec. Options follow the target separated by
+// semicolons: count=N, timeout=D (a duration, or milliseconds) and label=NAME.
+func Parse(s string) (Spec, error) {
+	parts := strings.Split(strings.TrimSpace(s), ";")
+	spec := Spec{
+		Raw:    strings.TrimSpace(s),
+		Target: strings.TrimSpace(parts[0]),
+	}
+	if spec.Target == "" {
+		return Spec{}, fmt.Errorf("invalid target %q: empty target", s)
+	}
+
+	if err := spec.parseTarget(); err != nil {
+		return Spec{}, fmt.Errorf("invalid target %q: %w", s, err)
+	}
+
+	for _, option := range parts[1:] {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+		if err := spec.parseOption(option); err != nil {
+			return Spec{}, fmt.Errorf("invalid target %q: %w", s, err)
+		}
+	}
+
+	return spec, nil
+}
+
+// ParseAll parses every string in specs
+func ParseAll(specs []string) ([]Spec, error) {
+	parsed := make([]Spec, 0, len(specs))
+	for _, s := range specs {
+		spec, err := Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, spec)
+	}
+	return parsed, nil
+}
+
+// parseTarget fills in the kind, scheme, host and port from spec.Target
+func (spec *Spec) parseTarget() error {
+	t := spec.Target
+
+	// URL targets such as tcp://host:443 or dns://server/name?type=A
+	if i := strings.Index(t, "://"); i > 0 {
+		u, err := url.Parse(t)
+		if err != nil {
+			return err
+		}
+		spec.Scheme = strings.ToLower(u.Scheme)
+		spec.Host = u.Hostname()
+		if spec.Host == "" {
+			return fmt.Errorf("missing host")
+		}
+		if u.Port() != "" {
+			port, err := parsePort(u.Port())
+			if err != nil {
+				return err
+			}
+			spec.Port = port
+		}
+		return nil
+	}
+
+	// Networks in CIDR notation
+	if strings.Contains(t, "/") {
+		ip, _, err := net.ParseCIDR(t)
+		if err != nil {
+			return err
+		}
+		if ip.To4() == nil {
+			return fmt.Errorf("only IPv4 CIDR notation is supported")
+		}
+		spec.Kind = KindCIDR
+		return nil
+	}
+
+	// Address ranges with dash notation
+	start, end, isRange := strings.Cut(t, "-")
+	startIP := net.ParseIP(strings.TrimSpace(start))
+	if isRange && startIP != nil && net.ParseIP(strings.TrimSpace(end)) != nil {
+		spec.Kind = KindRange
+		return nil
+	}
+
+	// nmap-style octet patterns such as 10.0-3.1-254.1 or 10.*.0.1
+	if isOctetPattern(t) {
+		if _, err := parseOctets(t); err != nil {
+			return err
+		}
+		spec.Kind = KindOctets
+		return nil
+	}
+	if isRange && startIP != nil {
+		return fmt.Errorf("invalid end IP: %s", strings.TrimSpace(end))
+	}
+
+	// Plain host names and addresses, optionally with a port
+	spec.Host = t
+	if host, port, err := net.SplitHostPort(t); err == nil {
+		spec.Host = host
+		spec.Port, err = parsePort(port)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseOption applies a single key=value option to spec
+func (spec *Spec) parseOption(option string) error {
+	key, value, ok := strings.Cut(option, "=")
+	if !ok {
+		return fmt.Errorf("option %q is not key=value", option)
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "count":
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 1 {
+			return fmt.Errorf("count must be a positive number, got %q", value)
+		}
+		spec.Count = count
+	case "timeout":
+		timeout, err := parseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q", value)
+		}
+		spec.Timeout = timeout
+	case "label":
+		if value == "" {
+			return fmt.Errorf("label must not be empty")
+		}
+		spec.Label = value
+	default:
+		return fmt.Errorf("unknown option %q", key)
+	}
+	return nil
+}
+
+// parsePort parses a port number between 1 and 65535
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// parseDuration parses a Go duration, plain numbers are milliseconds like -t
+func parseDuration(s string) (time.Duration, error) {
+	if ms, err := strconv.Atoi(s); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,178 @@
+package target
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Spec
+		wantErr  bool
+	}{
+		{
+			name:     "Plain address",
+			input:    "192.168.1.1",
+			expected: Spec{Raw: "192.168.1.1", Target: "192.168.1.1", Host: "192.168.1.1"},
+		},
+		{
+			name:     "Host with port",
+			input:    "example.com:8080",
+			expected: Spec{Raw: "example.com:8080", Target: "example.com:8080", Host: "example.com", Port: 8080},
+		},
+		{
+			name:     "Scheme",
+			input:    "icmp://core-rtr",
+			expected: Spec{Raw: "icmp://core-rtr", Target: "icmp://core-rtr", Scheme: "icmp", Host: "core-rtr"},
+		},
+		{
+			name:     "Scheme with port",
+			input:    "TCP://example.com:443",
+			expected: Spec{Raw: "TCP://example.com:443", Target: "TCP://example.com:443", Scheme: "tcp", Host: "example.com", Port: 443},
+		},
+		{
+			name:     "CIDR",
+			input:    "10.0.0.0/24",
+			expected: Spec{Raw: "10.0.0.0/24", Target: "10.0.0.0/24", Kind: KindCIDR},
+		},
+		{
+			name:     "Range",
+			input:    "10.0.0.1-10.0.0.50",
+			expected: Spec{Raw: "10.0.0.1-10.0.0.50", Target: "10.0.0.1-10.0.0.50", Kind: KindRange},
+		},
+		{
+			name:     "Host name with dash",
+			input:    "core-rtr-1",
+			expected: Spec{Raw: "core-rtr-1", Target: "core-rtr-1", Host: "core-rtr-1"},
+		},
+		{
+			name:  "Options",
+			input: "host;count=10;timeout=200ms;label=core-rtr",
+			expected: Spec{
+				Raw:     "host;count=10;timeout=200ms;label=core-rtr",
+				Target:  "host",
+				Host:    "host",
+				Count:   10,
+				Timeout: 200 * time.Millisecond,
+				Label:   "core-rtr",
+			},
+		},
+		{
+			name:  "Options with spaces and millisecond timeout",
+			input: "  host ; timeout = 150 ; ",
+			expected: Spec{
+				Raw:     "host ; timeout = 150 ;",
+				Target:  "host",
+				Host:    "host",
+				Timeout: 150 * time.Millisecond,
+			},
+		},
+		{
+			name:    "Empty target",
+			input:   ";count=1",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown option",
+			input:   "host;ttl=5",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid count",
+			input:   "host;count=0",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid timeout",
+			input:   "host;timeout=soon",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid CIDR",
+			input:   "10.0.0.0/33",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid range end",
+			input:   "10.0.0.1-10.0.0",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid port",
+			input:   "tcp://example.com:99999",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Parse(test.input)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr && !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("Parse() = %+v, want %+v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestSetKeepsOptions(t *testing.T) {
+	spec, err := Parse("192.168.1.1-192.168.1.3;count=3;label=lab")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	set, err := NewSet([]Spec{spec})
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+
+	got := Collect(set.All())
+	if len(got) != 3 {
+		t.Fatalf("All() returned %d specs, want 3", len(got))
+	}
+	for i, addr := range []string{"192.168.1.1", "192.168.1.2", "192.168.1.3"} {
+		if got[i].Target != addr || got[i].Host != addr || got[i].Kind != KindHost {
+			t.Errorf("All()[%d] = %+v, want host %s", i, got[i], addr)
+		}
+		if got[i].Count != 3 || got[i].Label != "lab" {
+			t.Errorf("All()[%d] did not keep the options: %+v", i, got[i])
+		}
+	}
+}
+
+func TestSetDuplicates(t *testing.T) {
+	specs, err := ParseAll([]string{
+		"192.168.1.2",
+		"192.168.1.0/30",
+		"example.com",
+		"192.168.1.1-192.168.1.3",
+		"example.com;count=5",
+	})
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+
+	set, err := NewSet(specs)
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+	got := Collect(set.All())
+
+	var targets []string
+	for _, spec := range got {
+		targets = append(targets, spec.Target)
+	}
+	expected := []string{"192.168.1.2", "192.168.1.1", "example.com", "192.168.1.3"}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Errorf("All() = %v, want %v", targets, expected)
+	}
+
+	// The first occurrence of a duplicate wins
+	if got[2].Count != 0 {
+		t.Errorf("All() kept count=%d from the duplicate example.com", got[2].Count)
+	}
+}
@@ -9,19 +9,23 @@ import (
 	"strings"
 )
 
-// ReadFromFile reads targets from a specified file, one per line
-func ReadFromFile(filename string) ([]string, error) {
+// ReadFromFile reads target specs from a specified file, one per line
+func ReadFromFile(filename string) ([]Spec, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	return readLines(file)
+	lines, err := readLines(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAll(lines)
 }
 
-// ReadFromStdin reads targets from standard input, one per line
-func ReadFromStdin() ([]string, error) {
+// ReadFromStdin reads target specs from standard input, one per line
+func ReadFromStdin() ([]Spec, error) {
 	// Check if there's data available on stdin
 	stat, err := os.Stdin.Stat()
 	if err != nil {
@@ -30,15 +34,19 @@ func ReadFromStdin() ([]string, error) {
 
 	// If there's no data from pipe or redirect, return empty slice
 	if (stat.Mode() & os.ModeCharDevice) != 0 {
-		return []string{}, nil
+		return []Spec{}, nil
 	}
 
-	return readLines(os.Stdin)
+	lines, err := readLines(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAll(lines)
 }
 
 // readLines reads lines from any io.Reader and returns non-empty lines
 func readLines(r io.Reader) ([]string, error) {
-	var targets []string
+	targets := []string{}
 	scanner := bufio.NewScanner(r)
 	
 	for scanner.Scan() {